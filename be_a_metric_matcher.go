@@ -37,8 +37,15 @@ func (m *BeAMetricMatcher) Match(actual any) (bool, error) {
 	return m.Expected.match(mf)
 }
 
+// FailureMessage additionally explains which of the expected properties the
+// actual metric family did or didn't match, in form of a checklist.
 func (m *BeAMetricMatcher) FailureMessage(actual any) string {
-	return format.Message(actual, "to match", m.Expected)
+	s := format.Message(actual, "to match", m.Expected)
+	mf, ok := actual.(*prommodel.MetricFamily)
+	if !ok || m.Expected == nil {
+		return s
+	}
+	return s + "\nchecklist:\n" + format.IndentString(m.Expected.explain(mf), 1)
 }
 
 func (m *BeAMetricMatcher) NegatedFailureMessage(actual any) string {
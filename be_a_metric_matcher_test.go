@@ -81,4 +81,63 @@ var _ = Describe("BeAMetric", func() {
 .*help: foobar`))
 	})
 
+	It("explains mismatches in a checklist", func() {
+		m := BeAMetric(Histogram(
+			HaveName("bottled_boris"),
+			HaveHelp("foobar"),
+			HaveUnit(Equal("booze")),
+			HaveLabel("type=champagne"),
+			NotHaveLabel("vintage"),
+			HaveSampleCount(42)))
+		Expect(m.Match(family)).To(BeFalse())
+		Expect(m.FailureMessage(family)).To(MatchRegexp(`
+checklist:
+    ✗ type: HISTOGRAM, got COUNTER
+    ✓ name: bottled_boris
+    ✗ help: foobar, got "beyond any"
+    ✓ unit: .*EqualMatcher`))
+		Expect(m.FailureMessage(family)).To(ContainSubstring(`
+    metric {type="champagne"}:
+        ✓ label {type=champagne}
+        ✓ no label {vintage}
+        ✗ sample count: 42, no sample count for COUNTER
+    metric {type="schaumwein"}:
+        ✗ label {type=champagne}, got {type="schaumwein"}
+        ✓ no label {vintage}
+        ✗ sample count: 42, no sample count for COUNTER`))
+	})
+
+	It("explains label and value mismatches", func() {
+		m := BeAMetric(Counter(
+			HaveLabel("vintage"),
+			NotHaveLabel("type"),
+			HaveSampleValue(1)))
+		Expect(m.Match(family)).To(BeFalse())
+		Expect(m.FailureMessage(family)).To(HaveSuffix(`
+checklist:
+    ✓ type: COUNTER
+    metric {type="champagne"}:
+        ✗ label {vintage}, no such label
+        ✗ no label {type}, got {type="champagne"}
+        ✗ value: 1, got 0
+    metric {type="schaumwein"}:
+        ✗ label {vintage}, no such label
+        ✗ no label {type}, got {type="schaumwein"}
+        ✗ value: 1, got 0`))
+		Expect(BeAMetric(AnyMetric(HaveLabel("foo"))).FailureMessage(
+			&prommodel.MetricFamily{Name: pstr("empty")})).To(HaveSuffix(`
+checklist:
+    ✓ type: ANY
+    ✗ metrics: none`))
+	})
+
+	It("produces a useful failure message for any metric type", func() {
+		m := BeAMetric(AnyMetric(HaveName("abc")))
+		Expect(m.Match(family)).To(BeFalse())
+		Expect(m.FailureMessage(family)).To(
+			MatchRegexp(
+				`ANY:
+.*name: abc`))
+	})
+
 })
@@ -0,0 +1,171 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	prommodel "github.com/prometheus/client_model/go"
+)
+
+const (
+	worstOffenders = 3 // number of worst offending labels or families to report.
+	exampleValues  = 3 // number of example label values to report.
+)
+
+// CardinalityBudget caps how many timeseries metric families may have, where
+// each individual metric of a metric family counts as a single timeseries, and
+// how many distinct values a label may take on. Limits left at zero are
+// unlimited. For instance, to catch cardinality explosions while linting:
+//
+//	LintWith(LimitCardinality(CardinalityBudget{
+//	    MaxTimeseriesPerFamily: 100,
+//	    MaxLabelValues:         20,
+//	})).GatherAndLint(reg)
+type CardinalityBudget struct {
+	// MaxTimeseriesPerFamily is the maximum number of timeseries per metric
+	// family.
+	MaxTimeseriesPerFamily int
+	// MaxLabelValues is the maximum number of distinct values per label of a
+	// metric family.
+	MaxLabelValues int
+	// MaxTotalTimeseries is the maximum number of timeseries across all metric
+	// families, not counting metric families whose linting problems are
+	// suppressed.
+	MaxTotalTimeseries int
+}
+
+// LimitCardinality adds the passed cardinality budget to the lint rules, so
+// that budget overruns are reported as linting problems, listing the worst
+// offending labels together with example values.
+func LimitCardinality(budget CardinalityBudget) LintOption {
+	return func(o *lintOptions) {
+		o.validations = append(o.validations, budget.Validate)
+		if budget.MaxTotalTimeseries > 0 {
+			o.budgets = append(o.budgets, budget)
+		}
+	}
+}
+
+// Validate checks the passed metric family against the per-family limits of
+// this cardinality budget, returning any overruns. As Validate only ever gets
+// to see a single metric family at a time, it cannot check the total number of
+// timeseries across all metric families; only [LimitCardinality] checks this
+// limit.
+func (b CardinalityBudget) Validate(mf *prommodel.MetricFamily) []error {
+	var problems []error
+	values := labelValues(mf)
+	if b.MaxTimeseriesPerFamily > 0 && len(mf.GetMetric()) > b.MaxTimeseriesPerFamily {
+		problems = append(problems, fmt.Errorf("metric family has %d timeseries, exceeding the budget of %d; worst labels: %s",
+			len(mf.GetMetric()), b.MaxTimeseriesPerFamily, worstLabels(values)))
+	}
+	if b.MaxLabelValues > 0 {
+		for _, name := range slices.Sorted(maps.Keys(values)) {
+			if len(values[name]) <= b.MaxLabelValues {
+				continue
+			}
+			problems = append(problems, fmt.Errorf("label %q has %d distinct values, exceeding the budget of %d, such as %s",
+				name, len(values[name]), b.MaxLabelValues, examples(values[name])))
+		}
+	}
+	return problems
+}
+
+// validateTotal returns an error if the total number of timeseries across all
+// passed metric families exceeds this cardinality budget, listing the largest
+// metric families. The caller is responsible for leaving out any metric
+// families whose linting problems are suppressed.
+func (b CardinalityBudget) validateTotal(metfams []*prommodel.MetricFamily) error {
+	if b.MaxTotalTimeseries <= 0 {
+		return nil
+	}
+	total := 0
+	for _, mf := range metfams {
+		total += len(mf.GetMetric())
+	}
+	if total <= b.MaxTotalTimeseries {
+		return nil
+	}
+	largest := slices.SortedStableFunc(slices.Values(metfams), func(a, b *prommodel.MetricFamily) int {
+		return cmp.Or(
+			cmp.Compare(len(b.GetMetric()), len(a.GetMetric())),
+			strings.Compare(a.GetName(), b.GetName()))
+	})
+	var s strings.Builder
+	for idx, mf := range largest[:min(worstOffenders, len(largest))] {
+		if idx > 0 {
+			s.WriteString(", ")
+		}
+		fmt.Fprintf(&s, "%s (%d)", mf.GetName(), len(mf.GetMetric()))
+	}
+	return fmt.Errorf("metric families have a total of %d timeseries, exceeding the budget of %d; largest families: %s",
+		total, b.MaxTotalTimeseries, s.String())
+}
+
+// labelValues returns the distinct values of each label of the passed metric
+// family.
+func labelValues(mf *prommodel.MetricFamily) map[string]map[string]struct{} {
+	values := map[string]map[string]struct{}{}
+	for _, metric := range mf.GetMetric() {
+		for _, label := range metric.GetLabel() {
+			if values[label.GetName()] == nil {
+				values[label.GetName()] = map[string]struct{}{}
+			}
+			values[label.GetName()][label.GetValue()] = struct{}{}
+		}
+	}
+	return values
+}
+
+// worstLabels returns a description of the labels with the most distinct
+// values, together with example values.
+func worstLabels(values map[string]map[string]struct{}) string {
+	if len(values) == 0 {
+		return "none"
+	}
+	names := slices.SortedFunc(maps.Keys(values), func(a, b string) int {
+		return cmp.Or(
+			cmp.Compare(len(values[b]), len(values[a])),
+			strings.Compare(a, b))
+	})
+	var s strings.Builder
+	for idx, name := range names[:min(worstOffenders, len(names))] {
+		if idx > 0 {
+			s.WriteString(", ")
+		}
+		fmt.Fprintf(&s, "%s (%d values, such as %s)", name, len(values[name]), examples(values[name]))
+	}
+	return s.String()
+}
+
+// examples returns a few example values from the passed set of values.
+func examples(values map[string]struct{}) string {
+	sorted := slices.Sorted(maps.Keys(values))
+	var s strings.Builder
+	for idx, value := range sorted[:min(exampleValues, len(sorted))] {
+		if idx > 0 {
+			s.WriteString(", ")
+		}
+		fmt.Fprintf(&s, "%q", value)
+	}
+	if len(sorted) > exampleValues {
+		s.WriteString(", ...")
+	}
+	return s.String()
+}
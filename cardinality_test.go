@@ -0,0 +1,90 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+	prommodel "github.com/prometheus/client_model/go"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("cardinality budgets", func() {
+
+	requests := func(paths int) prometheus.Collector {
+		c := prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "requests_total",
+			Help: "requests.",
+		}, []string{"code", "path"})
+		for idx := range paths {
+			c.WithLabelValues(fmt.Sprintf("%d", 200+idx%2), fmt.Sprintf("/%d", idx)).Inc()
+		}
+		return c
+	}
+
+	It("doesn't limit anything by default", func() {
+		Expect(CardinalityBudget{}.Validate(&prommodel.MetricFamily{})).To(BeEmpty())
+		Expect(CardinalityBudget{}.validateTotal(nil)).To(Succeed())
+	})
+
+	It("checks per-family budgets", func() {
+		mf := CollectAndLint(requests(5))["requests_total"]
+		Expect(CardinalityBudget{MaxTimeseriesPerFamily: 5, MaxLabelValues: 5}.Validate(mf)).To(BeEmpty())
+		Expect(CardinalityBudget{MaxTimeseriesPerFamily: 4, MaxLabelValues: 2}.Validate(mf)).To(HaveExactElements(
+			MatchError(`metric family has 5 timeseries, exceeding the budget of 4; worst labels: path (5 values, such as "/0", "/1", "/2", ...), code (2 values, such as "200", "201")`),
+			MatchError(`label "path" has 5 distinct values, exceeding the budget of 2, such as "/0", "/1", "/2", ...`)))
+		Expect(CardinalityBudget{MaxTimeseriesPerFamily: 1}.Validate(&prommodel.MetricFamily{
+			Metric: []*prommodel.Metric{{}, {}},
+		})).To(ConsistOf(MatchError(HaveSuffix("worst labels: none"))))
+	})
+
+	It("checks total budgets", func() {
+		metfams := []*prommodel.MetricFamily{
+			{Name: pstr("foo"), Metric: []*prommodel.Metric{{}}},
+			{Name: pstr("bar"), Metric: []*prommodel.Metric{{}, {}}},
+			{Name: pstr("baz"), Metric: []*prommodel.Metric{{}}},
+			{Name: pstr("qux"), Metric: []*prommodel.Metric{{}}},
+		}
+		Expect(CardinalityBudget{MaxTotalTimeseries: 5}.validateTotal(metfams)).To(Succeed())
+		Expect(CardinalityBudget{MaxTotalTimeseries: 4}.validateTotal(metfams)).To(MatchError(
+			"metric families have a total of 5 timeseries, exceeding the budget of 4; largest families: bar (2), baz (1), foo (1)"))
+	})
+
+	It("reports budget overruns as linting problems", func() {
+		reg := prometheus.NewPedanticRegistry()
+		Expect(reg.Register(requests(3))).To(Succeed())
+		Expect(LintWith(LimitCardinality(CardinalityBudget{MaxTotalTimeseries: 3})).GatherAndLint(reg)).To(HaveLen(1))
+		Expect(LintWith(LimitCardinality(CardinalityBudget{
+			MaxTotalTimeseries: 2,
+			MaxLabelValues:     2,
+		})).Gather(reg)()).Error().To(MatchError(
+			"linting problems:\n" +
+				"metric families have a total of 3 timeseries, exceeding the budget of 2; largest families: requests_total (3)\n" +
+				`requests_total: label "path" has 3 distinct values, exceeding the budget of 2, such as "/0", "/1", "/2"`))
+	})
+
+	It("doesn't count suppressed metric families towards the total budget", func() {
+		reg := prometheus.NewPedanticRegistry()
+		Expect(reg.Register(requests(3))).To(Succeed())
+		Expect(LintWith(
+			LimitCardinality(CardinalityBudget{MaxTotalTimeseries: 2}),
+			SuppressLintProblems("requests_total"),
+		).Gather(reg)()).Error().NotTo(HaveOccurred())
+	})
+
+})
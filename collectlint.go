@@ -15,9 +15,12 @@
 package pyrotest
 
 import (
+	"errors"
+	"fmt"
 	"iter"
 	"maps"
 	"slices"
+	"strings"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil/promlint"
@@ -37,14 +40,15 @@ import (
 // If any metric names are passed in, only metrics with those names are checked.
 func CollectAndLint(coll prometheus.Collector, metricNames ...string) MetricsFamilies {
 	gi.GinkgoHelper()
-	return collectAndLint(gom.Default, coll, metricNames...)
+	return collectAndLint(gom.Default, nil, coll, metricNames...)
 }
 
-func collectAndLint(gomega types.Gomega, coll prometheus.Collector, metricNames ...string) MetricsFamilies {
+func collectAndLint(gomega types.Gomega, lo *lintOptions, coll prometheus.Collector, metricNames ...string) MetricsFamilies {
 	gi.GinkgoHelper()
+	THelper(gomega)()
 	reg := prometheus.NewPedanticRegistry()
 	gomega.Expect(reg.Register(coll)).To(gom.Succeed(), "registering collector failed")
-	return gatherAndLint(gomega, reg, metricNames...)
+	return gatherAndLint(gomega, lo, reg, metricNames...)
 }
 
 // GatherAndLint gathers all metrics from the passed-in [prometheus.Gatherer],
@@ -55,26 +59,83 @@ func collectAndLint(gomega types.Gomega, coll prometheus.Collector, metricNames
 // If any metric names are passed in, only metrics with those names are checked.
 func GatherAndLint(g prometheus.Gatherer, metricNames ...string) MetricsFamilies {
 	gi.GinkgoHelper()
-	return gatherAndLint(gom.Default, g, metricNames...)
+	return gatherAndLint(gom.Default, nil, g, metricNames...)
 }
 
-func gatherAndLint(gomega types.Gomega, g prometheus.Gatherer, metricNames ...string) MetricsFamilies {
+func gatherAndLint(gomega types.Gomega, lo *lintOptions, g prometheus.Gatherer, metricNames ...string) MetricsFamilies {
 	gi.GinkgoHelper()
+	THelper(gomega)()
 
 	metfams, err := g.Gather()
 	gomega.Expect(err).NotTo(gom.HaveOccurred(), "gathering metrics failed")
 
+	return lint(gomega, lo, metfams, metricNames...)
+}
+
+// lint lints the passed metric families, optionally only those with the
+// specified names, and returns them as a metric families map if there are
+// neither errors nor linting problems. Otherwise, it fails the current test.
+func lint(gomega types.Gomega, lo *lintOptions, metfams []*prommodel.MetricFamily, metricNames ...string) MetricsFamilies {
+	gi.GinkgoHelper()
+	THelper(gomega)()
+
 	if len(metricNames) != 0 {
 		metfams = filterMetrics(metfams, metricNames)
 	}
 
-	problems, err := promlint.NewWithMetricFamilies(metfams).Lint()
-	gomega.Expect(err).NotTo(gom.HaveOccurred(), "linting error")
-	gomega.Expect(problems).To(gom.BeEmpty(), "linting problems")
+	gomega.Expect(lo.problems(metfams)).To(gom.BeEmpty(), "linting problems")
 
 	return maps.Collect(allFamilies(metfams))
 }
 
+// Gather returns a function that gathers all metrics from the passed-in
+// [prometheus.Gatherer] and lints them, returning the metrics only if there are
+// neither errors nor linting problems; otherwise, it returns an error
+// describing the gathering or linting problems. In contrast to
+// [GatherAndLint], the returned function never fails the current test, so it
+// can be used as a polling function with Gomega's Eventually, such as:
+//
+//	Eventually(Gather(reg)).Should(ContainMetrics(
+//	    Counter(HaveName("jobs_total"), HaveSampleValue(1))))
+//
+// Eventually retries as long as the returned function returns a non-nil error
+// or the metrics do not yet match.
+//
+// If any metric names are passed in, only metrics with those names are checked.
+func Gather(g prometheus.Gatherer, metricNames ...string) func() (MetricsFamilies, error) {
+	return gather(nil, g, metricNames...)
+}
+
+func gather(lo *lintOptions, g prometheus.Gatherer, metricNames ...string) func() (MetricsFamilies, error) {
+	return func() (MetricsFamilies, error) {
+		metfams, err := g.Gather()
+		if err != nil {
+			return nil, fmt.Errorf("gathering metrics failed: %w", err)
+		}
+		if len(metricNames) != 0 {
+			metfams = filterMetrics(metfams, metricNames)
+		}
+		if problems := lo.problems(metfams); len(problems) != 0 {
+			return nil, lintProblemsError(problems)
+		}
+		return maps.Collect(allFamilies(metfams)), nil
+	}
+}
+
+// lintProblemsError returns an error listing the passed linting problems.
+func lintProblemsError(problems []promlint.Problem) error {
+	var s strings.Builder
+	s.WriteString("linting problems:")
+	for _, problem := range problems {
+		if problem.Metric == "" {
+			fmt.Fprintf(&s, "\n%s", problem.Text)
+			continue
+		}
+		fmt.Fprintf(&s, "\n%s: %s", problem.Metric, problem.Text)
+	}
+	return errors.New(s.String())
+}
+
 // allFamilies returns an iterator over all metricfamilies elements, producing
 // key-value pairs with the metric family names as keys and the metric families
 // as their values.
@@ -15,6 +15,7 @@
 package pyrotest
 
 import (
+	"errors"
 	"maps"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -96,6 +97,30 @@ var _ = Describe("collecting/gathering, and linting metric families", func() {
 			})))
 	})
 
+	It("gathers eventually", func() {
+		reg := prometheus.NewPedanticRegistry()
+		c := prometheus.NewCounter(prometheus.CounterOpts{Name: "jobs_total", Help: "jobs."})
+		Expect(reg.Register(c)).To(Succeed())
+		go func() {
+			defer GinkgoRecover()
+			c.Inc()
+		}()
+		Eventually(Gather(reg)).Should(ContainMetrics(
+			Counter(HaveName("jobs_total"), HaveSampleValue(1))))
+		Expect(Gather(reg, "foo")()).To(BeEmpty())
+	})
+
+	It("returns gathering and linting problems as errors", func() {
+		Expect(Gather(prometheus.GathererFunc(func() ([]*prommodel.MetricFamily, error) {
+			return nil, errors.New("D'OH!")
+		}))()).Error().To(MatchError("gathering metrics failed: D'OH!"))
+
+		reg := prometheus.NewPedanticRegistry()
+		Expect(reg.Register(&fishyCollector{})).To(Succeed())
+		Expect(Gather(reg)()).Error().To(MatchError(
+			ContainSubstring(`linting problems:` + "\n" + `foo: counter metrics should have "_total" suffix`)))
+	})
+
 	When("things fail", Serial, func() {
 
 		var g Gomega
@@ -106,12 +131,12 @@ var _ = Describe("collecting/gathering, and linting metric families", func() {
 		})
 
 		It("fails given an invalid collector", func() {
-			collectAndLint(g, &brokenCollector{})
+			collectAndLint(g, nil, &brokenCollector{})
 			Expect(msg).To(ContainSubstring("have inconsistent label names or help strings"))
 		})
 
 		It("fails given linting problems", func() {
-			collectAndLint(g, &fishyCollector{})
+			collectAndLint(g, nil, &fishyCollector{})
 			Expect(msg).To(ContainSubstring("counter metrics should have \\\"_total\\\" suffix"))
 		})
 
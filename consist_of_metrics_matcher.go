@@ -0,0 +1,177 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+	prommodel "github.com/prometheus/client_model/go"
+)
+
+// ConsistOfMetricsMatcher is a [types.GomegaMatcher] that succeeds if an actual
+// value is assignable to a [MetricsFamilies] map and that metric families map
+// contains all expected metrics and nothing else.
+type ConsistOfMetricsMatcher struct {
+	ExpectedMetrics []MetricMatcher
+	missingMetrics  []MetricMatcher
+	extraFamilies   []string
+}
+
+var _ types.GomegaMatcher = (*ConsistOfMetricsMatcher)(nil)
+
+// ConsistOfMetrics succeeds if actual represents a [MetricsFamilies] map that
+// consists of exactly the passed-in metrics described by [MetricMatcher]
+// elements: each expected metric must match its own metric family and there
+// must be no metric families left over.
+//
+// In contrast to [ContainMetrics], ConsistOfMetrics finds a one-to-one
+// assignment between expected metrics and metric families, even if some
+// expected metrics match multiple metric families.
+func ConsistOfMetrics(ms ...MetricMatcher) types.GomegaMatcher {
+	return &ConsistOfMetricsMatcher{
+		ExpectedMetrics: ms,
+	}
+}
+
+func (m *ConsistOfMetricsMatcher) Match(actual any) (bool, error) {
+	familiesMap, ok := asFamiliesMap(actual)
+	if !ok {
+		return false, fmt.Errorf(
+			"ConsistOfMetrics matcher expects a non-nil map of metric families, indexed by their names.  Got:\n%s",
+			format.Object(actual, 1))
+	}
+	m.missingMetrics = nil
+	m.extraFamilies = nil
+
+	// work on the family names in a stable order, so that the matching as
+	// well as the failure reports are deterministic.
+	names := slices.Sorted(maps.Keys(familiesMap))
+	nameIndices := map[string]int{}
+	for idx, name := range names {
+		nameIndices[name] = idx
+	}
+
+	// determine for each expected metric the metric families it matches;
+	// where possible, we directly look up the family by its plain name.
+	candidates := make([][]int, len(m.ExpectedMetrics))
+	for matcherIdx, matcher := range m.ExpectedMetrics {
+		if name := matcher.indexname(); name != "" {
+			familyIdx, ok := nameIndices[name]
+			if !ok {
+				continue
+			}
+			ok, err := matchFamily(matcher, familiesMap[name])
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				candidates[matcherIdx] = []int{familyIdx}
+			}
+			continue
+		}
+		for familyIdx, name := range names {
+			ok, err := matchFamily(matcher, familiesMap[name])
+			if err != nil {
+				return false, err
+			}
+			if ok {
+				candidates[matcherIdx] = append(candidates[matcherIdx], familyIdx)
+			}
+		}
+	}
+
+	assignedMatchers := maximumBipartiteMatching(candidates, len(names))
+	for familyIdx, matcherIdx := range assignedMatchers {
+		if matcherIdx < 0 {
+			m.extraFamilies = append(m.extraFamilies, names[familyIdx])
+		}
+	}
+	assigned := make([]bool, len(m.ExpectedMetrics))
+	for _, matcherIdx := range assignedMatchers {
+		if matcherIdx >= 0 {
+			assigned[matcherIdx] = true
+		}
+	}
+	for matcherIdx, matcher := range m.ExpectedMetrics {
+		if !assigned[matcherIdx] {
+			m.missingMetrics = append(m.missingMetrics, matcher)
+		}
+	}
+	return len(m.missingMetrics) == 0 && len(m.extraFamilies) == 0, nil
+}
+
+// matchFamily returns whether the passed metric family matches, where a nil
+// family never matches.
+func matchFamily(matcher MetricMatcher, family *prommodel.MetricFamily) (bool, error) {
+	if family == nil {
+		return false, nil
+	}
+	return matcher.match(family)
+}
+
+// maximumBipartiteMatching returns a maximum matching between expected
+// matchers and actual elements, such as metric families or labels, given for
+// each matcher the list of actual elements it matches. The result contains for
+// each actual element the index of the matcher assigned to it, or -1 if no
+// matcher has been assigned.
+//
+// This uses the classic augmenting path algorithm (Kuhn's algorithm), which is
+// more than sufficient for the small numbers of metrics and labels found in
+// tests.
+func maximumBipartiteMatching(candidates [][]int, numActual int) []int {
+	assigned := make([]int, numActual)
+	for idx := range assigned {
+		assigned[idx] = -1
+	}
+	var augment func(matcherIdx int, visited []bool) bool
+	augment = func(matcherIdx int, visited []bool) bool {
+		for _, actualIdx := range candidates[matcherIdx] {
+			if visited[actualIdx] {
+				continue
+			}
+			visited[actualIdx] = true
+			if assigned[actualIdx] < 0 || augment(assigned[actualIdx], visited) {
+				assigned[actualIdx] = matcherIdx
+				return true
+			}
+		}
+		return false
+	}
+	for matcherIdx := range candidates {
+		augment(matcherIdx, make([]bool, numActual))
+	}
+	return assigned
+}
+
+func (m *ConsistOfMetricsMatcher) FailureMessage(actual any) string {
+	s := format.Message(actual, "to consist of", m.ExpectedMetrics)
+	if len(m.missingMetrics) != 0 {
+		s = fmt.Sprintf("%s\nthe missing elements were\n%s",
+			s, format.Object(m.missingMetrics, 1))
+	}
+	if len(m.extraFamilies) != 0 {
+		s = fmt.Sprintf("%s\nthe extra metric families were\n%s",
+			s, format.Object(m.extraFamilies, 1))
+	}
+	return s
+}
+
+func (m *ConsistOfMetricsMatcher) NegatedFailureMessage(actual any) string {
+	return format.Message(actual, "not to consist of", m.ExpectedMetrics)
+}
@@ -0,0 +1,103 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	prommodel "github.com/prometheus/client_model/go"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ConsistOfMetricsMatcher", func() {
+
+	famsmap := map[string]*prommodel.MetricFamily{
+		"bottled_boris": {
+			Type: prommodel.MetricType_COUNTER.Enum(),
+			Name: pstr("bottled_boris"),
+			Unit: pstr("booze"),
+			Metric: []*prommodel.Metric{
+				{Label: []*prommodel.LabelPair{{Name: pstr("type"), Value: pstr("champagne")}}},
+				{Label: []*prommodel.LabelPair{{Name: pstr("type"), Value: pstr("schaumwein")}}},
+			},
+		},
+		"angry_angie": {
+			Type: prommodel.MetricType_GAUGE.Enum(),
+			Name: pstr("angry_angie"),
+			Unit: pstr("snooze"),
+			Metric: []*prommodel.Metric{
+				{Label: []*prommodel.LabelPair{{Name: pstr("realm"), Value: pstr("east")}}},
+				{Label: []*prommodel.LabelPair{{Name: pstr("realm"), Value: pstr("west")}}},
+			},
+		},
+	}
+
+	It("rejects actual values if they're not families maps", func() {
+		Expect(ConsistOfMetrics().Match(nil)).Error().To(MatchError(
+			ContainSubstring("ConsistOfMetrics matcher expects a non-nil map of metric families")))
+		Expect(ConsistOfMetrics().Match(42)).Error().To(MatchError(
+			ContainSubstring("ConsistOfMetrics matcher expects a non-nil map of metric families")))
+	})
+
+	It("returns the error of a sub matcher", func() {
+		Expect(ConsistOfMetrics(Gauge(HaveName(42))).Match(famsmap)).Error().To(MatchError(
+			ContainSubstring("to be either a string or GomegaMatcher")))
+		Expect(ConsistOfMetrics(Counter(HaveName("bottled_boris"), HaveHelp(42))).Match(famsmap)).Error().To(MatchError(
+			ContainSubstring("to be either a string or GomegaMatcher")))
+	})
+
+	It("succeeds when all metrics are matched and there are no others", func() {
+		Expect(map[string]*prommodel.MetricFamily{}).To(ConsistOfMetrics())
+		Expect(famsmap).To(ConsistOfMetrics(
+			Counter(HaveName("bottled_boris")),
+			Gauge(HaveLabel("realm=east"))))
+	})
+
+	It("finds a one-to-one assignment instead of greedily matching", func() {
+		Expect(famsmap).To(ConsistOfMetrics(
+			AnyMetric(),
+			Gauge()))
+		Expect(famsmap).NotTo(ConsistOfMetrics(
+			Gauge(),
+			Gauge(HaveName(HaveSuffix("_angie")))))
+	})
+
+	It("never matches nil families", func() {
+		Expect(map[string]*prommodel.MetricFamily{"foo": nil}).NotTo(ConsistOfMetrics(AnyMetric()))
+		Expect(map[string]*prommodel.MetricFamily{"foo": nil}).NotTo(ConsistOfMetrics(AnyMetric(HaveName("foo"))))
+	})
+
+	It("reports missing metrics", func() {
+		m := ConsistOfMetrics(
+			Counter(HaveName("bottled_boris")),
+			Gauge(HaveName("angry_angie")),
+			Gauge(HaveName("pritti_prattl")))
+		Expect(m.Match(famsmap)).To(BeFalse())
+		msg := m.FailureMessage(famsmap)
+		Expect(msg).To(MatchRegexp(`the missing elements were\n(.*\n)*.*GAUGE:\n.*name: pritti_prattl`))
+		Expect(msg).NotTo(ContainSubstring("the extra metric families were"))
+	})
+
+	It("reports extra metric families", func() {
+		m := ConsistOfMetrics(
+			Counter(HaveName("bottled_boris")))
+		Expect(m.Match(famsmap)).To(BeFalse())
+		msg := m.FailureMessage(famsmap)
+		Expect(msg).To(MatchRegexp(`the extra metric families were\n.*\["angry_angie"\]`))
+		Expect(msg).NotTo(ContainSubstring("the missing elements were"))
+		Expect(m.NegatedFailureMessage(famsmap)).To(ContainSubstring("not to consist of"))
+	})
+
+})
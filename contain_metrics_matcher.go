@@ -16,6 +16,7 @@ package pyrotest
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/onsi/gomega/format"
 	"github.com/onsi/gomega/types"
@@ -57,6 +58,7 @@ func (m *ContainMetricsMatcher) Match(actual any) (bool, error) {
 			format.Object(actual, 1))
 	}
 
+	m.missingMetrics = nil
 	// first, do any fast direct family-by-name lookups where they are
 	// possible...
 	var lastError error
@@ -126,7 +128,8 @@ nextFamily:
 	// we were successful when we could match all remaining/slow expected
 	// metrics; otherwise, if we still have expected metrics to match but
 	// exhausted all our metric families, then we have failed.
-	return len(slowExpecteds) == 0 && len(m.missingMetrics) == 0, nil
+	m.missingMetrics = append(m.missingMetrics, slowExpecteds...)
+	return len(m.missingMetrics) == 0, nil
 }
 
 func (m *ContainMetricsMatcher) FailureMessage(actual any) string {
@@ -134,8 +137,33 @@ func (m *ContainMetricsMatcher) FailureMessage(actual any) string {
 	if len(m.missingMetrics) == 0 {
 		return s
 	}
-	return fmt.Sprintf("%s\nthe missing elements were\n%s",
-		s, format.Object(m.missingMetrics, 1))
+	return fmt.Sprintf("%s\nthe missing elements were\n%s%s",
+		s, format.Object(m.missingMetrics, 1), m.nearMisses(actual))
+}
+
+// nearMisses returns the closest matches in the actual metric families for
+// each of the missing expected metrics, if any.
+func (m *ContainMetricsMatcher) nearMisses(actual any) string {
+	familiesMap, ok := asFamiliesMap(actual)
+	if !ok {
+		return ""
+	}
+	var s strings.Builder
+	for _, missing := range m.missingMetrics {
+		nm, ok := missing.(nearMisser)
+		if !ok {
+			continue
+		}
+		misses := nm.nearMisses(familiesMap)
+		if len(misses) == 0 {
+			continue
+		}
+		fmt.Fprintf(&s, "\nclosest matches for missing %s:", nm.nearMissSubject())
+		for _, miss := range misses {
+			s.WriteString("\n" + format.Indent + miss)
+		}
+	}
+	return s.String()
 }
 
 func (m *ContainMetricsMatcher) NegatedFailureMessage(actual any) string {
@@ -83,4 +83,52 @@ var _ = Describe("ContainMetricsMatcher", func() {
 			Gauge(HaveName("angry_angie"), HaveLabel("foo=bar"))))
 	})
 
+	It("reports near misses", func() {
+		m := ContainMetrics(
+			Gauge(HaveName("bottled_boris")),
+			Counter(HaveName("bottled_borris")),
+			Counter(HaveName("bottled_boris"), HaveUnit("snooze")),
+			Gauge(HaveName("angry_angie"), HaveLabel("realm=east"), HaveLabel("weather=sunny")),
+			Gauge(HaveName(HavePrefix("angry")), HaveLabel("realm=north")),
+			Counter(HaveName("nonexisting")))
+		Expect(m.Match(famsmap)).To(BeFalse())
+		msg := m.FailureMessage(famsmap)
+		Expect(msg).To(ContainSubstring("\nclosest matches for missing GAUGE \"bottled_boris\":\n" +
+			`    metric family "bottled_boris" has type COUNTER instead of GAUGE`))
+		Expect(msg).To(ContainSubstring("\nclosest matches for missing COUNTER \"bottled_borris\":\n" +
+			`    metric family "bottled_boris" of type COUNTER has a similar name`))
+		Expect(msg).To(ContainSubstring("\nclosest matches for missing COUNTER \"bottled_boris\":\n" +
+			`    metric family "bottled_boris" mismatches unit: snooze`))
+		Expect(msg).To(ContainSubstring("\nclosest matches for missing GAUGE \"angry_angie\":\n" +
+			`    metric angry_angie{realm="east"} matches all but label {weather=sunny}` + "\n"))
+		Expect(msg).To(ContainSubstring("\nclosest matches for missing GAUGE metric:\n" +
+			`    metric angry_angie{realm="east"} matches all but label {realm=north}` + "\n" +
+			`    metric angry_angie{realm="west"} matches all but label {realm=north}`))
+		Expect(msg).NotTo(ContainSubstring(`missing COUNTER "nonexisting"`))
+	})
+
+	It("limits near misses", func() {
+		m := ContainMetrics(Gauge(HaveName("foo"), HaveLabel("bar")))
+		metrics := []*prommodel.Metric{}
+		for range 7 {
+			metrics = append(metrics, &prommodel.Metric{})
+		}
+		fams := MetricsFamilies{"foo": {
+			Type:   prommodel.MetricType_GAUGE.Enum(),
+			Name:   pstr("foo"),
+			Metric: metrics,
+		}}
+		Expect(m.Match(fams)).To(BeFalse())
+		Expect(m.FailureMessage(fams)).To(HaveSuffix("\n    ...and 2 more"))
+	})
+
+	It("calculates edit distances", func() {
+		Expect(editDistance("", "")).To(Equal(0))
+		Expect(editDistance("foo", "")).To(Equal(3))
+		Expect(editDistance("kitten", "sitting")).To(Equal(3))
+		Expect(similarNames("foo_total", MetricsFamilies{
+			"foo_total": nil, "foo_totals": nil, "fo_totl": nil, "bar_total": nil, "foo": nil,
+		})).To(Equal([]string{"foo_totals", "fo_totl"}))
+	})
+
 })
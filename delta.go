@@ -0,0 +1,266 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/prometheus/client_golang/prometheus"
+	prommodel "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+
+	gi "github.com/onsi/ginkgo/v2"
+	gom "github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+)
+
+// Snapshot is a point-in-time copy of metric families, such as taken before
+// and after some operation under test, in order to then assert the [Delta]
+// between both. A Snapshot lives only in memory for the duration of a test;
+// not to be confused with the golden-file snapshots of [MatchMetricsSnapshot].
+type Snapshot struct {
+	families MetricsFamilies
+}
+
+// Families returns the metric families of this snapshot.
+func (s Snapshot) Families() MetricsFamilies {
+	return s.families
+}
+
+// TakeSnapshot gathers all metrics from the passed-in [prometheus.Gatherer],
+// returning them as a [Snapshot]. If gathering fails, TakeSnapshot will fail
+// the current test.
+func TakeSnapshot(g prometheus.Gatherer) Snapshot {
+	gi.GinkgoHelper()
+	return takeSnapshot(gom.Default, g)
+}
+
+// TakeCollectorSnapshot collects all metrics from the passed-in
+// [prometheus.Collector], returning them as a [Snapshot]. If collecting fails,
+// TakeCollectorSnapshot will fail the current test. TakeCollectorSnapshot uses
+// a newly created pedantic [prometheus.Registry].
+func TakeCollectorSnapshot(coll prometheus.Collector) Snapshot {
+	gi.GinkgoHelper()
+	return takeCollectorSnapshot(gom.Default, coll)
+}
+
+func takeCollectorSnapshot(gomega types.Gomega, coll prometheus.Collector) Snapshot {
+	gi.GinkgoHelper()
+	THelper(gomega)()
+	reg := prometheus.NewPedanticRegistry()
+	gomega.Expect(reg.Register(coll)).To(gom.Succeed(), "registering collector failed")
+	return takeSnapshot(gomega, reg)
+}
+
+func takeSnapshot(gomega types.Gomega, g prometheus.Gatherer) Snapshot {
+	gi.GinkgoHelper()
+	THelper(gomega)()
+	metfams, err := g.Gather()
+	gomega.Expect(err).NotTo(gom.HaveOccurred(), "gathering metrics failed")
+	return Snapshot{families: maps.Collect(allFamilies(metfams))}
+}
+
+// Delta returns the per-timeseries differences between the before and after
+// snapshots as a [MetricsFamilies] map, so that the differences can be
+// asserted using [ContainMetrics] and the usual metric matchers, such as:
+//
+//	Expect(Delta(before, after)).To(ContainMetrics(
+//	    Counter(HaveName("requests_total"),
+//	        HaveLabel("code=200"),
+//	        HaveIncreasedBy(1))))
+//
+// The differences cover the values of counters, gauges, and untyped metrics,
+// the sample counts, sample sums, and classic bucket counts of histograms, as
+// well as the sample counts and sample sums of summaries. Timeseries only
+// present in the after snapshot are considered to have been zero before.
+// Timeseries only present in the before snapshot are not part of the delta.
+//
+// Following Prometheus' “increase” semantics, a counter, (non-gauge) histogram,
+// or summary timeseries with a lower (sample count) value after than before is
+// considered to have been reset, so its difference is its after value.
+//
+// Summary quantiles, native histogram buckets, exemplars, and timestamps are
+// not part of the delta.
+func Delta(before, after Snapshot) MetricsFamilies {
+	delta := MetricsFamilies{}
+	for name, afterFamily := range after.families {
+		if afterFamily == nil {
+			continue
+		}
+		var beforeMetrics map[string]*prommodel.Metric
+		if beforeFamily := before.families[name]; beforeFamily.GetType() == afterFamily.GetType() {
+			beforeMetrics = metricsByLabels(beforeFamily)
+		}
+		deltaFamily := &prommodel.MetricFamily{
+			Name: afterFamily.Name,
+			Help: afterFamily.Help,
+			Type: afterFamily.Type,
+			Unit: afterFamily.Unit,
+		}
+		for _, afterMetric := range afterFamily.GetMetric() {
+			deltaFamily.Metric = append(deltaFamily.Metric, metricDelta(
+				afterFamily.GetType(),
+				beforeMetrics[labelsString(afterMetric.GetLabel())],
+				afterMetric))
+		}
+		delta[name] = deltaFamily
+	}
+	return delta
+}
+
+// metricsByLabels returns the metrics of the passed family, indexed by their
+// textual label representation.
+func metricsByLabels(family *prommodel.MetricFamily) map[string]*prommodel.Metric {
+	metrics := map[string]*prommodel.Metric{}
+	for _, metric := range family.GetMetric() {
+		metrics[labelsString(metric.GetLabel())] = metric
+	}
+	return metrics
+}
+
+// metricDelta returns the difference between the before and after metric of
+// the specified type. A nil before metric is considered to be all zero.
+func metricDelta(typ prommodel.MetricType, before, after *prommodel.Metric) *prommodel.Metric {
+	delta := &prommodel.Metric{Label: after.Label}
+	switch typ {
+	case prommodel.MetricType_COUNTER:
+		delta.Counter = &prommodel.Counter{
+			Value: proto.Float64(increase(before.GetCounter().GetValue(), after.GetCounter().GetValue())),
+		}
+	case prommodel.MetricType_GAUGE:
+		delta.Gauge = &prommodel.Gauge{
+			Value: proto.Float64(after.GetGauge().GetValue() - before.GetGauge().GetValue()),
+		}
+	case prommodel.MetricType_UNTYPED:
+		delta.Untyped = &prommodel.Untyped{
+			Value: proto.Float64(after.GetUntyped().GetValue() - before.GetUntyped().GetValue()),
+		}
+	case prommodel.MetricType_SUMMARY:
+		beforeSummary, afterSummary := before.GetSummary(), after.GetSummary()
+		if afterSummary.GetSampleCount() < beforeSummary.GetSampleCount() {
+			beforeSummary = nil
+		}
+		delta.Summary = &prommodel.Summary{
+			SampleCount: proto.Uint64(afterSummary.GetSampleCount() - beforeSummary.GetSampleCount()),
+			SampleSum:   proto.Float64(afterSummary.GetSampleSum() - beforeSummary.GetSampleSum()),
+		}
+	case prommodel.MetricType_HISTOGRAM, prommodel.MetricType_GAUGE_HISTOGRAM:
+		delta.Histogram = histogramDelta(before.GetHistogram(), after.GetHistogram(),
+			typ == prommodel.MetricType_HISTOGRAM)
+	}
+	return delta
+}
+
+// histogramDelta returns the difference between the before and after
+// histograms, based on their sample counts, sample sums, and classic buckets.
+// Buckets are matched by their upper bounds; buckets only present in the after
+// histogram are considered to have been empty before. Only if resettable is
+// true, a lower sample count after than before is considered to be a reset.
+func histogramDelta(before, after *prommodel.Histogram, resettable bool) *prommodel.Histogram {
+	if resettable && histogramSampleCount(after) < histogramSampleCount(before) {
+		before = nil // reset
+	}
+	beforeCounts := map[float64]float64{}
+	for _, bucket := range before.GetBucket() {
+		beforeCounts[bucket.GetUpperBound()] = bucketCount(bucket)
+	}
+	delta := &prommodel.Histogram{
+		SampleCountFloat: proto.Float64(histogramSampleCount(after) - histogramSampleCount(before)),
+		SampleSum:        proto.Float64(after.GetSampleSum() - before.GetSampleSum()),
+	}
+	for _, bucket := range after.GetBucket() {
+		delta.Bucket = append(delta.Bucket, &prommodel.Bucket{
+			UpperBound:           bucket.UpperBound,
+			CumulativeCountFloat: proto.Float64(bucketCount(bucket) - beforeCounts[bucket.GetUpperBound()]),
+		})
+	}
+	return delta
+}
+
+// increase returns the increase of a counter value, taking counter resets into
+// account.
+func increase(before, after float64) float64 {
+	if after < before {
+		return after
+	}
+	return after - before
+}
+
+// ----
+
+// MetricIncreaseMatcher matches the increase of an individual metric in a
+// [Delta], that is, the value of a counter, gauge, or untyped metric, and the
+// sample count of a histogram or summary.
+type MetricIncreaseMatcher struct {
+	matcher  types.GomegaMatcher
+	expected any // original expected value for error reporting.
+}
+
+var (
+	_ (MetricPropertyMatcher)   = (*MetricIncreaseMatcher)(nil)
+	_ (individualMetricMatcher) = (*MetricIncreaseMatcher)(nil)
+	_ (mismatchReasoner)        = (*MetricIncreaseMatcher)(nil)
+	_ (format.GomegaStringer)   = (*MetricIncreaseMatcher)(nil)
+)
+
+func (m *MetricIncreaseMatcher) GomegaString() string {
+	return fmt.Sprintf("increased by: %s", numberString(m.expected))
+}
+
+func (m *MetricIncreaseMatcher) yesimametricpropertymatcher() {}
+
+// matchMetric matches the increase of the passed metric, taking the type of
+// its metric family into account.
+func (m *MetricIncreaseMatcher) matchMetric(mf *prommodel.MetricFamily, metric *prommodel.Metric) (bool, error) {
+	if m.matcher == nil {
+		return false, errors.New(format.Message(
+			m.expected, "to be either a number or GomegaMatcher"))
+	}
+	increase, ok := metricIncrease(mf, metric)
+	if !ok {
+		return false, nil
+	}
+	return m.matcher.Match(increase)
+}
+
+func (m *MetricIncreaseMatcher) mismatchReason(mf *prommodel.MetricFamily, metric *prommodel.Metric) string {
+	increase, ok := metricIncrease(mf, metric)
+	if !ok {
+		return "no increase for " + mf.GetType().String()
+	}
+	return "increased by " + FormatFloat(increase)
+}
+
+// metricIncrease returns the increase of the passed metric in a [Delta], that
+// is, the value of a counter, gauge, or untyped metric, and the sample count of
+// a histogram or summary. It returns false for metrics of other types.
+func metricIncrease(mf *prommodel.MetricFamily, metric *prommodel.Metric) (float64, bool) {
+	switch {
+	case mf.GetType() == prommodel.MetricType_COUNTER:
+		return metric.GetCounter().GetValue(), true
+	case mf.GetType() == prommodel.MetricType_GAUGE:
+		return metric.GetGauge().GetValue(), true
+	case mf.GetType() == prommodel.MetricType_UNTYPED:
+		return metric.GetUntyped().GetValue(), true
+	case isHistogramType(mf.GetType()):
+		return histogramSampleCount(metric.GetHistogram()), true
+	case mf.GetType() == prommodel.MetricType_SUMMARY:
+		return float64(metric.GetSummary().GetSampleCount()), true
+	}
+	return 0, false
+}
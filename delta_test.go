@@ -0,0 +1,132 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	prommodel "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("metric deltas", func() {
+
+	It("computes deltas between snapshots", func() {
+		reg := prometheus.NewPedanticRegistry()
+		c := prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "requests_total",
+			Help: "requests.",
+		}, []string{"code"})
+		c.WithLabelValues("200").Add(41)
+		g := prometheus.NewGauge(prometheus.GaugeOpts{Name: "temperature", Help: "temp."})
+		g.Set(42)
+		h := prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "duration_seconds",
+			Help:    "durations.",
+			Buckets: []float64{0.1, 1},
+		})
+		h.Observe(0.05)
+		s := prometheus.NewSummary(prometheus.SummaryOpts{Name: "sizes", Help: "sizes."})
+		s.Observe(100)
+		Expect(reg.Register(c)).To(Succeed())
+		Expect(reg.Register(g)).To(Succeed())
+		Expect(reg.Register(h)).To(Succeed())
+		Expect(reg.Register(s)).To(Succeed())
+
+		before := TakeSnapshot(reg)
+		Expect(before.Families()).To(HaveLen(4))
+
+		c.WithLabelValues("200").Inc()
+		c.WithLabelValues("404").Add(2)
+		g.Set(40)
+		h.Observe(0.5)
+		h.Observe(0.7)
+		s.Observe(10)
+
+		after := TakeSnapshot(reg)
+		Expect(Delta(before, after)).To(ConsistOfMetrics(
+			Counter(HaveName("requests_total"), HaveLabel("code=200"), HaveIncreasedBy(1)),
+			Gauge(HaveName("temperature"), HaveIncreasedBy(-2)),
+			Histogram(HaveName("duration_seconds"),
+				HaveIncreasedBy(2),
+				HaveSampleSum(BeNumerically("~", 1.2, 1e-9)),
+				HaveBucket(0.1, 0),
+				HaveBucket(1, 2)),
+			Summary(HaveName("sizes"), HaveIncreasedBy(1), HaveSampleSum(10)),
+		))
+		Expect(Delta(before, after)).To(ContainMetrics(
+			Counter(HaveName("requests_total"), HaveLabel("code=404"), HaveIncreasedBy(2))))
+		Expect(Delta(before, after)).NotTo(ContainMetrics(
+			Counter(HaveName("requests_total"), HaveLabel("code=200"), HaveIncreasedBy(2))))
+	})
+
+	It("takes collector snapshots", func() {
+		c := prometheus.NewCounter(prometheus.CounterOpts{Name: "foo_total", Help: "foo."})
+		before := TakeCollectorSnapshot(c)
+		c.Add(5)
+		Expect(Delta(before, TakeCollectorSnapshot(c))).To(ConsistOfMetrics(
+			Counter(HaveName("foo_total"), HaveIncreasedBy(5))))
+	})
+
+	It("handles resets and vanished timeseries", func() {
+		counter := func(value float64) *prommodel.MetricFamily {
+			return &prommodel.MetricFamily{
+				Name: proto.String("foo_total"),
+				Type: prommodel.MetricType_COUNTER.Enum(),
+				Metric: []*prommodel.Metric{
+					{Counter: &prommodel.Counter{Value: proto.Float64(value)}},
+				},
+			}
+		}
+		gaugeHistogram := func(count uint64) *prommodel.MetricFamily {
+			return &prommodel.MetricFamily{
+				Name: proto.String("bar"),
+				Type: prommodel.MetricType_GAUGE_HISTOGRAM.Enum(),
+				Metric: []*prommodel.Metric{
+					{Histogram: &prommodel.Histogram{SampleCount: proto.Uint64(count)}},
+				},
+			}
+		}
+		before := Snapshot{families: MetricsFamilies{
+			"foo_total": counter(10),
+			"bar":       gaugeHistogram(5),
+			"baz":       counter(1),
+		}}
+		after := Snapshot{families: MetricsFamilies{
+			"foo_total": counter(3),
+			"bar":       gaugeHistogram(2),
+		}}
+		Expect(Delta(before, after)).To(ConsistOfMetrics(
+			Counter(HaveName("foo_total"), HaveIncreasedBy(3)),
+			GaugeHistogram(HaveName("bar"), HaveIncreasedBy(-3))))
+	})
+
+	It("never matches metrics without increase", func() {
+		Expect((&MetricIncreaseMatcher{matcher: Equal(0.0)}).matchMetric(
+			&prommodel.MetricFamily{Type: prommodel.MetricType(42).Enum()},
+			&prommodel.Metric{})).To(BeFalse())
+	})
+
+	It("reports invalid expected increases", func() {
+		m := HaveIncreasedBy("foo").(*MetricIncreaseMatcher)
+		Expect(m.GomegaString()).To(HavePrefix("increased by: "))
+		Expect(m.matchMetric(
+			&prommodel.MetricFamily{Type: prommodel.MetricType_COUNTER.Enum()},
+			&prommodel.Metric{})).Error().To(MatchError(ContainSubstring("to be either a number or GomegaMatcher")))
+	})
+
+})
@@ -0,0 +1,206 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged context lines surrounding changes in
+// unified diffs.
+const diffContext = 3
+
+// diffOp is a single line operation of an edit script, where kind is either
+// ' ' for an unchanged line, '-' for a deleted line, or '+' for an inserted
+// line.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// unifiedDiff returns the differences between the expected and actual texts in
+// unified diff format, using the passed names in the file header lines. If
+// both texts are identical, unifiedDiff returns an empty string.
+//
+// Lines are compared including their line endings, so a missing final newline
+// is marked with the usual “\ No newline at end of file”. As CR characters
+// aren't visible, differing CRLF versus LF line endings are additionally
+// explained in a final note.
+func unifiedDiff(expectedName, actualName, expected, actual string) string {
+	if expected == actual {
+		return ""
+	}
+	ops := editScript(splitLines(expected), splitLines(actual))
+	var s strings.Builder
+	fmt.Fprintf(&s, "--- %s\n+++ %s\n", expectedName, actualName)
+	for start := 0; start < len(ops); {
+		// skip unchanged lines until we find the next change, then determine
+		// the extent of the hunk, merging changes that are close to each
+		// other.
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		last := first
+		for idx := first; idx < len(ops); idx++ {
+			if ops[idx].kind == ' ' {
+				if idx-last > 2*diffContext {
+					break
+				}
+				continue
+			}
+			last = idx
+		}
+		from := max(first-diffContext, start)
+		to := min(last+diffContext+1, len(ops))
+		writeHunk(&s, ops, from, to)
+		start = to
+	}
+	expectedEndings, actualEndings := lineEndings(expected), lineEndings(actual)
+	if expectedEndings != actualEndings {
+		fmt.Fprintf(&s, "\\ line endings differ: %s uses %s, %s uses %s\n",
+			expectedName, expectedEndings, actualName, actualEndings)
+	}
+	return s.String()
+}
+
+// lineEndings returns the style of line endings used in the passed text,
+// either “CRLF” or “LF”.
+func lineEndings(text string) string {
+	if strings.Contains(text, "\r\n") {
+		return "CRLF"
+	}
+	return "LF"
+}
+
+// writeHunk writes the hunk of the edit script ranging from the “from” index
+// up to, but not including, the “to” index.
+func writeHunk(s *strings.Builder, ops []diffOp, from, to int) {
+	expectedLine, actualLine := 1, 1
+	for _, op := range ops[:from] {
+		if op.kind != '+' {
+			expectedLine++
+		}
+		if op.kind != '-' {
+			actualLine++
+		}
+	}
+	expectedLen, actualLen := 0, 0
+	for _, op := range ops[from:to] {
+		if op.kind != '+' {
+			expectedLen++
+		}
+		if op.kind != '-' {
+			actualLen++
+		}
+	}
+	fmt.Fprintf(s, "@@ -%s +%s @@\n",
+		hunkRange(expectedLine, expectedLen), hunkRange(actualLine, actualLen))
+	for _, op := range ops[from:to] {
+		line, eol := strings.CutSuffix(op.line, "\n")
+		s.WriteByte(op.kind)
+		s.WriteString(strings.TrimSuffix(line, "\r"))
+		s.WriteByte('\n')
+		if !eol {
+			s.WriteString("\\ No newline at end of file\n")
+		}
+	}
+}
+
+// hunkRange returns the range of a hunk in unified diff notation.
+func hunkRange(line, length int) string {
+	switch length {
+	case 0:
+		return fmt.Sprintf("%d,0", line-1)
+	case 1:
+		return fmt.Sprintf("%d", line)
+	}
+	return fmt.Sprintf("%d,%d", line, length)
+}
+
+// splitLines splits the passed text into its individual lines, keeping their
+// line endings. Only the final line might lack a line ending.
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// editScript returns an edit script transforming the lines a into the lines b,
+// based on the longest common subsequence of the lines. Common prefix and
+// suffix lines are trimmed beforehand in order to keep the quadratic part
+// small in the usual case of only a few differences.
+func editScript(a, b []string) []diffOp {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	ops := make([]diffOp, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{kind: ' ', line: line})
+	}
+	midA, midB := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	// lcs[i][j] is the length of the longest common subsequence of midA[i:]
+	// and midB[j:].
+	lcs := make([][]int, len(midA)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(midB)+1)
+	}
+	for i := len(midA) - 1; i >= 0; i-- {
+		for j := len(midB) - 1; j >= 0; j-- {
+			if midA[i] == midB[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(midA) && j < len(midB) {
+		switch {
+		case midA[i] == midB[j]:
+			ops = append(ops, diffOp{kind: ' ', line: midA[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{kind: '-', line: midA[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', line: midB[j]})
+			j++
+		}
+	}
+	for ; i < len(midA); i++ {
+		ops = append(ops, diffOp{kind: '-', line: midA[i]})
+	}
+	for ; j < len(midB); j++ {
+		ops = append(ops, diffOp{kind: '+', line: midB[j]})
+	}
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{kind: ' ', line: line})
+	}
+	return ops
+}
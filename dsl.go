@@ -15,7 +15,11 @@
 package pyrotest
 
 import (
+	"fmt"
+	"slices"
+
 	"github.com/onsi/gomega/types"
+	"github.com/prometheus/client_golang/prometheus"
 	prommodel "github.com/prometheus/client_model/go"
 )
 
@@ -44,7 +48,9 @@ func BeAMetric(m MetricMatcher) types.GomegaMatcher {
 // like name, unit, help, and labels.
 type MetricMatcher interface {
 	match(*prommodel.MetricFamily) (bool, error)
+	matchIndividual(*prommodel.MetricFamily, *prommodel.Metric) (bool, error)
 	indexname() string
+	explain(*prommodel.MetricFamily) string
 }
 
 // MetricPropertyMatcher identifies metric family property or metric property
@@ -69,11 +75,41 @@ func Counter(props ...MetricPropertyMatcher) MetricMatcher {
 
 // Histogram succeeds if a metric (metric family) is a Prometheus Histogram and
 // additionally satisfies all optionally specified name, help, and labels
-// matchers.
+// matchers. Use [HaveHistogramKind] to differentiate between classic, native,
+// and hybrid histograms.
 func Histogram(props ...MetricPropertyMatcher) MetricMatcher {
 	return metricOfType(prommodel.MetricType_HISTOGRAM, props...)
 }
 
+// Summary succeeds if a metric (metric family) is a Prometheus Summary and
+// additionally satisfies all optionally specified name, help, and labels
+// matchers.
+func Summary(props ...MetricPropertyMatcher) MetricMatcher {
+	return metricOfType(prommodel.MetricType_SUMMARY, props...)
+}
+
+// Untyped succeeds if a metric (metric family) is an untyped Prometheus metric
+// and additionally satisfies all optionally specified name, help, and labels
+// matchers.
+func Untyped(props ...MetricPropertyMatcher) MetricMatcher {
+	return metricOfType(prommodel.MetricType_UNTYPED, props...)
+}
+
+// GaugeHistogram succeeds if a metric (metric family) is a Prometheus
+// GaugeHistogram and additionally satisfies all optionally specified name,
+// help, and labels matchers.
+func GaugeHistogram(props ...MetricPropertyMatcher) MetricMatcher {
+	return metricOfType(prommodel.MetricType_GAUGE_HISTOGRAM, props...)
+}
+
+// AnyMetric succeeds if a metric (metric family) of any type satisfies all
+// optionally specified name, help, and labels matchers.
+func AnyMetric(props ...MetricPropertyMatcher) MetricMatcher {
+	m := metricOfType(prommodel.MetricType_UNTYPED, props...).(*TypedMetricFamilyMatcher)
+	m.anyType = true
+	return m
+}
+
 // HaveLabel succeeds if a metric has a label with the specified name (and
 // optional value).
 //
@@ -103,6 +139,302 @@ func HaveLabelWithValue(name, value any) MetricPropertyMatcher {
 	return newHaveLabelMatcher(name, value, "HaveLabelWithValue")
 }
 
+// HaveSampleValue succeeds if an individual counter, gauge, or untyped metric
+// has a sample value that either equals the passed number or matches the
+// passed GomegaMatcher, such as:
+//
+//	Counter(HaveName("foo_total"),
+//	    HaveLabel("code=200"),
+//	    HaveSampleValue(BeNumerically(">", 0)))
+//
+// The sample value is matched on the same individual metric that also matches
+// any specified labels.
+//
+// Please note that this matcher cannot be named “HaveValue” as it otherwise
+// would conflict with Gomega's HaveValue when dot-importing.
+func HaveSampleValue(value any) MetricPropertyMatcher {
+	return &MetricValueMatcher{
+		matcher:  asNumberMatcher(value),
+		expected: value,
+	}
+}
+
+// HaveSampleCount succeeds if an individual histogram or summary metric has a
+// sample count that either equals the passed number or matches the passed
+// GomegaMatcher. The sample count is matched on the same individual metric that
+// also matches any specified labels.
+func HaveSampleCount(count any) MetricPropertyMatcher {
+	return &MetricSampleCountMatcher{
+		matcher:  asNumberMatcher(count),
+		expected: count,
+	}
+}
+
+// HaveSampleSum succeeds if an individual histogram or summary metric has a
+// sample sum that either equals the passed number or matches the passed
+// GomegaMatcher. The sample sum is matched on the same individual metric that
+// also matches any specified labels.
+func HaveSampleSum(sum any) MetricPropertyMatcher {
+	return &MetricSampleSumMatcher{
+		matcher:  asNumberMatcher(sum),
+		expected: sum,
+	}
+}
+
+// HaveIncreasedBy succeeds if an individual metric in a [Delta] has increased
+// by the passed number or by a value matching the passed GomegaMatcher, such
+// as:
+//
+//	before := TakeSnapshot(reg)
+//	doSomething()
+//	Expect(Delta(before, TakeSnapshot(reg))).To(ContainMetrics(
+//	    Counter(HaveName("requests_total"),
+//	        HaveLabel("code=200"),
+//	        HaveIncreasedBy(1))))
+//
+// For counters, gauges, and untyped metrics the increase is the difference in
+// their values, whereas for histograms and summaries it is the difference in
+// their sample counts. Use [HaveSampleSum] and [HaveBucket] to additionally
+// assert the differences in sample sums and bucket counts.
+func HaveIncreasedBy(delta any) MetricPropertyMatcher {
+	return &MetricIncreaseMatcher{
+		matcher:  asNumberMatcher(delta),
+		expected: delta,
+	}
+}
+
+// HaveBucket succeeds if an individual (classic) histogram metric has a bucket
+// with the specified upper bound and a cumulative count that either equals the
+// passed number or matches the passed GomegaMatcher. Use math.Inf(+1) to
+// specify the “+Inf” bucket, if present in the actual histogram.
+func HaveBucket(upperBound float64, cumulativeCount any) MetricPropertyMatcher {
+	return &HistogramBucketMatcher{
+		upperBound: upperBound,
+		matcher:    asNumberMatcher(cumulativeCount),
+		expected:   cumulativeCount,
+	}
+}
+
+// HaveBucketBounds succeeds if an individual (classic) histogram metric has
+// buckets with exactly the specified upper bounds, in the same order. An
+// explicit “+Inf” bucket is ignored, so the bounds used when creating a
+// histogram, such as prometheus.DefBuckets, can be passed in directly.
+func HaveBucketBounds(bounds []float64) MetricPropertyMatcher {
+	return &HistogramBucketBoundsMatcher{
+		bounds: slices.Clone(bounds),
+	}
+}
+
+// HaveHistogramKind succeeds if an individual histogram metric is of the
+// specified kind, that is, a classic, native, or hybrid histogram:
+//
+//	Histogram(HaveName("foo_duration_seconds"), HaveHistogramKind(NativeHistogram))
+func HaveHistogramKind(kind HistogramKind) MetricPropertyMatcher {
+	return &HistogramKindMatcher{
+		kind: kind,
+	}
+}
+
+// HaveSchema succeeds if an individual native (or hybrid) histogram metric has
+// a schema that either equals the passed number or matches the passed
+// GomegaMatcher. Classic histograms never match.
+func HaveSchema(schema any) MetricPropertyMatcher {
+	return &NativeHistogramPropertyMatcher{
+		property: "schema",
+		value:    func(h *prommodel.Histogram) float64 { return float64(h.GetSchema()) },
+		matcher:  asNumberMatcher(schema),
+		expected: schema,
+	}
+}
+
+// HaveZeroThreshold succeeds if an individual native (or hybrid) histogram
+// metric has a zero threshold that either equals the passed number or matches
+// the passed GomegaMatcher. Classic histograms never match.
+func HaveZeroThreshold(threshold any) MetricPropertyMatcher {
+	return &NativeHistogramPropertyMatcher{
+		property: "zero threshold",
+		value:    (*prommodel.Histogram).GetZeroThreshold,
+		matcher:  asNumberMatcher(threshold),
+		expected: threshold,
+	}
+}
+
+// HaveZeroCount succeeds if an individual native (or hybrid) histogram metric
+// has a zero bucket count that either equals the passed number or matches the
+// passed GomegaMatcher. Classic histograms never match.
+func HaveZeroCount(count any) MetricPropertyMatcher {
+	return &NativeHistogramPropertyMatcher{
+		property: "zero count",
+		value:    zeroCount,
+		matcher:  asNumberMatcher(count),
+		expected: count,
+	}
+}
+
+// HaveNativeBucket succeeds if an individual native (or hybrid) histogram
+// metric has a native bucket with the specified lower and upper bounds and a
+// (non-cumulative) count that either equals the passed number or matches the
+// passed GomegaMatcher. The native buckets are decoded from the spans and
+// delta-encoded counts of the histogram, with positive buckets covering the
+// interval (lower, upper] and negative buckets covering [lower, upper). As
+// native bucket bounds are calculated, they are compared with a tiny relative
+// tolerance.
+//
+// For instance, the bucket of schema 3 with index 0 would be specified as:
+//
+//	HaveNativeBucket(math.Exp2(-1.0/8), 1, 42)
+func HaveNativeBucket(lower, upper float64, count any) MetricPropertyMatcher {
+	return &NativeHistogramBucketMatcher{
+		lower:    lower,
+		upper:    upper,
+		matcher:  asNumberMatcher(count),
+		expected: count,
+	}
+}
+
+// HaveQuantile succeeds if an individual summary metric has the specified
+// quantile (such as 0.99) with a value that either equals the passed number or
+// matches the passed GomegaMatcher. For instance, to check that a quantile
+// exists and is within some tolerance:
+//
+//	Summary(HaveName("foo_duration_seconds"),
+//	    HaveQuantile(0.5, BeNumerically("~", 0.25, 0.01)))
+//
+// When passing a nil value, HaveQuantile succeeds if the quantile exists,
+// regardless of its value.
+func HaveQuantile(quantile float64, value any) MetricPropertyMatcher {
+	return &SummaryQuantileMatcher{
+		quantile: quantile,
+		matcher:  asNumberMatcher(value),
+		expected: value,
+	}
+}
+
+// HaveQuantiles succeeds if an individual summary metric has exactly the
+// specified quantiles (such as 0.5, 0.9, 0.99), in any order, regardless of
+// their values.
+func HaveQuantiles(quantiles ...float64) MetricPropertyMatcher {
+	return &SummaryQuantilesMatcher{
+		quantiles: slices.Sorted(slices.Values(quantiles)),
+	}
+}
+
+// HaveExemplar succeeds if an individual counter or histogram metric has an
+// exemplar that satisfies all optionally specified exemplar label, value, and
+// timestamp matchers. For histograms, the exemplars of the classic buckets as
+// well as the native histogram exemplars are taken into account.
+//
+// The exemplar labels are matched using [HaveLabel] and [HaveLabelWithValue],
+// the exemplar value using [HaveSampleValue], and the exemplar timestamp using
+// [HaveTimestamp]:
+//
+//	Counter(HaveName("foo_total"),
+//	    HaveExemplar(HaveLabel("trace_id=4bf92f3577b34da6"), HaveSampleValue(1)))
+//
+// Passing any other property matcher panics.
+func HaveExemplar(props ...MetricPropertyMatcher) MetricPropertyMatcher {
+	m := &ExemplarMatcher{}
+	for _, propm := range props {
+		switch matcher := propm.(type) {
+		case metricLabelMatcher:
+			m.labelMatchers = append(m.labelMatchers, matcher)
+		case *MetricValueMatcher:
+			m.valueMatcher = matcher
+		case *TimestampMatcher:
+			m.timestampMatcher = matcher
+		default:
+			panic(fmt.Sprintf("unsupported exemplar property matcher of type %T", propm))
+		}
+	}
+	return m
+}
+
+// HaveTimestamp succeeds if an individual metric or an exemplar has a timestamp
+// that either equals the passed time.Time or matches the passed GomegaMatcher,
+// such as BeTemporally. Individual metrics without an explicit timestamp never
+// match.
+func HaveTimestamp(timestamp any) MetricPropertyMatcher {
+	return &TimestampMatcher{
+		matcher:  asTimeMatcher(timestamp),
+		expected: timestamp,
+	}
+}
+
+// NotHaveLabel succeeds if an individual metric does not have a label with the
+// specified name (and optional value). The label absence is checked on the
+// same individual metric that also matches any other specified labels and
+// individual metric properties. In contrast, wrapping a metric matcher in
+// Gomega's Not() negates the match of the whole metric instead.
+//
+// The value passed into the label parameter can be either a string or
+// GomegaMatcher:
+//   - a string in the form of “name” where a label name must not match, or
+//     in the “name=value” form where the label name and value must not match
+//     together.
+//   - a GomegaMatcher that matches the name only.
+//   - any other type of value is an error.
+//
+// See also [WithoutLabel].
+func NotHaveLabel(label any) MetricPropertyMatcher {
+	return &NotHaveLabelMatcher{
+		label: newHaveLabelMatcher(label, nil, "NotHaveLabel").(*HaveLabelMatcher),
+	}
+}
+
+// WithoutLabel is an alias for [NotHaveLabel] that might read more naturally
+// in some expectations, such as:
+//
+//	Counter(HaveLabel("code=200"), WithoutLabel("user_id"))
+func WithoutLabel(label any) MetricPropertyMatcher {
+	return NotHaveLabel(label)
+}
+
+// HaveExactLabels succeeds if an individual metric has exactly the specified
+// labels, no more and no fewer. Each label is specified in the same way as for
+// [HaveLabel]:
+//   - a string in the form of “name” where it must match a label name, or
+//     in the “name=value” form where it must match both the label name and
+//     value.
+//   - a GomegaMatcher that matches the name only.
+//
+// Expected and actual labels are matched one-to-one: each expected label needs
+// its own actual label, so a GomegaMatcher matching multiple label names
+// accounts only for one of them.
+//
+// In contrast, [HaveLabel] and [HaveLabelWithValue] succeed if the specified
+// labels are a subset of the labels of an individual metric.
+//
+// See also [HaveOnlyLabels].
+func HaveExactLabels(labels ...any) MetricPropertyMatcher {
+	return newHaveExactLabelsMatcher(labels...)
+}
+
+// HaveOnlyLabels succeeds if an individual metric has exactly the specified
+// labels with the specified values, no more and no fewer.
+//
+// See also [HaveExactLabels].
+func HaveOnlyLabels(labels prometheus.Labels) MetricPropertyMatcher {
+	return newHaveOnlyLabelsMatcher(labels)
+}
+
+// HaveTimeseriesCount succeeds if a metric family has a number of individual
+// metrics (timeseries) that either equals the passed number or matches the
+// passed GomegaMatcher, for instance, to catch cardinality explosions:
+//
+//	Counter(HaveName("foo_total"), HaveTimeseriesCount(BeNumerically("<=", 10)))
+//
+// If any label matchers, such as [HaveLabel] or [NotHaveLabel], are passed,
+// only those individual metrics with matching labels are counted. Please note
+// that these label matchers are independent of any other label matchers of the
+// same metric (family) matcher. Passing any other property matcher panics.
+func HaveTimeseriesCount(count any, labels ...MetricPropertyMatcher) MetricPropertyMatcher {
+	return &TimeseriesCountMatcher{
+		matcher:  asNumberMatcher(count),
+		expected: count,
+		filter:   newLabelFilter(labels),
+	}
+}
+
 // HaveName succeeds if a metric family has a name that either equals the passed
 // string or matches the passed GomegaMatcher.
 func HaveName(name any) MetricPropertyMatcher {
@@ -0,0 +1,211 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+	prommodel "github.com/prometheus/client_model/go"
+)
+
+// exemplars returns all exemplars of an individual metric: the exemplar of a
+// counter, or the exemplars of the classic buckets as well as the native
+// exemplars of a histogram.
+func exemplars(mf *prommodel.MetricFamily, metric *prommodel.Metric) []*prommodel.Exemplar {
+	switch {
+	case mf.GetType() == prommodel.MetricType_COUNTER:
+		if e := metric.GetCounter().GetExemplar(); e != nil {
+			return []*prommodel.Exemplar{e}
+		}
+	case isHistogramType(mf.GetType()):
+		h := metric.GetHistogram()
+		var es []*prommodel.Exemplar
+		for _, bucket := range h.GetBucket() {
+			if e := bucket.GetExemplar(); e != nil {
+				es = append(es, e)
+			}
+		}
+		return append(es, h.GetExemplars()...)
+	}
+	return nil
+}
+
+// ----
+
+// ExemplarMatcher matches an exemplar of an individual counter or histogram
+// metric, based on the exemplar's labels, value, and timestamp.
+type ExemplarMatcher struct {
+	labelMatchers    []metricLabelMatcher
+	valueMatcher     *MetricValueMatcher
+	timestampMatcher *TimestampMatcher
+}
+
+var (
+	_ (MetricPropertyMatcher)   = (*ExemplarMatcher)(nil)
+	_ (individualMetricMatcher) = (*ExemplarMatcher)(nil)
+	_ (mismatchReasoner)        = (*ExemplarMatcher)(nil)
+	_ (format.GomegaStringer)   = (*ExemplarMatcher)(nil)
+)
+
+func (m *ExemplarMatcher) GomegaString() string {
+	var s strings.Builder
+	s.WriteString("exemplar")
+	for _, label := range m.labelMatchers {
+		s.WriteRune('\n')
+		s.WriteString(format.IndentString(label.(format.GomegaStringer).GomegaString(), 1))
+	}
+	if m.valueMatcher != nil {
+		s.WriteRune('\n')
+		s.WriteString(format.IndentString(m.valueMatcher.GomegaString(), 1))
+	}
+	if m.timestampMatcher != nil {
+		s.WriteRune('\n')
+		s.WriteString(format.IndentString(m.timestampMatcher.GomegaString(), 1))
+	}
+	return s.String()
+}
+
+func (m *ExemplarMatcher) yesimametricpropertymatcher() {}
+
+// matchMetric succeeds if any exemplar of the passed metric satisfies all
+// expected exemplar labels, value, and timestamp.
+func (m *ExemplarMatcher) matchMetric(mf *prommodel.MetricFamily, metric *prommodel.Metric) (bool, error) {
+	for _, exemplar := range exemplars(mf, metric) {
+		success, err := m.matchExemplar(exemplar)
+		if err != nil {
+			return false, err
+		}
+		if success {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (m *ExemplarMatcher) mismatchReason(mf *prommodel.MetricFamily, metric *prommodel.Metric) string {
+	es := exemplars(mf, metric)
+	if len(es) == 0 {
+		return "no exemplars"
+	}
+	reasons := make([]string, 0, len(es))
+	for _, exemplar := range es {
+		reasons = append(reasons, exemplarString(exemplar))
+	}
+	return "got " + strings.Join(reasons, ", ")
+}
+
+// exemplarString returns a concise textual representation of the passed
+// exemplar, consisting of its value, labels, and optional timestamp.
+func exemplarString(exemplar *prommodel.Exemplar) string {
+	s := fmt.Sprintf("exemplar value %s with labels %s",
+		FormatFloat(exemplar.GetValue()), labelsString(exemplar.GetLabel()))
+	if ts := exemplar.GetTimestamp(); ts != nil {
+		s += " at " + ts.AsTime().Format(time.RFC3339Nano)
+	}
+	return s
+}
+
+func (m *ExemplarMatcher) matchExemplar(exemplar *prommodel.Exemplar) (bool, error) {
+	success, err := matchAllLabels(exemplar.GetLabel(), m.labelMatchers)
+	if err != nil || !success {
+		return false, err
+	}
+	if m.valueMatcher != nil {
+		if m.valueMatcher.matcher == nil {
+			return false, errors.New(format.Message(
+				m.valueMatcher.expected, "to be either a number or GomegaMatcher"))
+		}
+		success, err := m.valueMatcher.matcher.Match(exemplar.GetValue())
+		if err != nil || !success {
+			return false, err
+		}
+	}
+	if m.timestampMatcher != nil {
+		ts := exemplar.GetTimestamp()
+		if ts == nil {
+			return false, nil
+		}
+		return m.timestampMatcher.matchTimestamp(ts.AsTime())
+	}
+	return true, nil
+}
+
+// ----
+
+// TimestampMatcher matches the timestamp of an individual metric or exemplar.
+type TimestampMatcher struct {
+	matcher  types.GomegaMatcher
+	expected any // original expected value for error reporting.
+}
+
+var (
+	_ (MetricPropertyMatcher)   = (*TimestampMatcher)(nil)
+	_ (individualMetricMatcher) = (*TimestampMatcher)(nil)
+	_ (mismatchReasoner)        = (*TimestampMatcher)(nil)
+	_ (format.GomegaStringer)   = (*TimestampMatcher)(nil)
+)
+
+func (m *TimestampMatcher) GomegaString() string {
+	if ts, ok := m.expected.(time.Time); ok {
+		return fmt.Sprintf("timestamp: %s", ts.Format(time.RFC3339Nano))
+	}
+	return fmt.Sprintf("timestamp: %s", format.Object(m.expected, 1))
+}
+
+func (m *TimestampMatcher) yesimametricpropertymatcher() {}
+
+// matchMetric matches the (optional) timestamp of an individual metric; if the
+// metric has no explicit timestamp, it never matches.
+func (m *TimestampMatcher) matchMetric(_ *prommodel.MetricFamily, metric *prommodel.Metric) (bool, error) {
+	if metric.TimestampMs == nil {
+		return false, nil
+	}
+	return m.matchTimestamp(time.UnixMilli(metric.GetTimestampMs()))
+}
+
+func (m *TimestampMatcher) mismatchReason(_ *prommodel.MetricFamily, metric *prommodel.Metric) string {
+	if metric.TimestampMs == nil {
+		return "no timestamp"
+	}
+	return "got " + time.UnixMilli(metric.GetTimestampMs()).Format(time.RFC3339Nano)
+}
+
+func (m *TimestampMatcher) matchTimestamp(ts time.Time) (bool, error) {
+	if m.matcher == nil {
+		return false, errors.New(format.Message(
+			m.expected, "to be either a time.Time or GomegaMatcher"))
+	}
+	return m.matcher.Match(ts)
+}
+
+// asTimeMatcher expects a to be either a time.Time or a types.GomegaMatcher
+// and then always returns a suitable types.GomegaMatcher, otherwise nil in case
+// of an unsupported value type of a.
+func asTimeMatcher(a any) types.GomegaMatcher {
+	switch v := a.(type) {
+	case time.Time:
+		return gomega.BeTemporally("==", v)
+	case types.GomegaMatcher:
+		return v
+	default:
+		return nil
+	}
+}
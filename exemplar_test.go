@@ -0,0 +1,179 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"time"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+	"github.com/prometheus/client_golang/prometheus"
+	prommodel "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("exemplar matchers", func() {
+
+	ts := time.Date(2025, 4, 1, 12, 0, 0, 0, time.UTC)
+
+	counterFamily := &prommodel.MetricFamily{
+		Name: pstr("foo_total"),
+		Type: prommodel.MetricType_COUNTER.Enum(),
+		Metric: []*prommodel.Metric{
+			{
+				Label: []*prommodel.LabelPair{{Name: pstr("code"), Value: pstr("200")}},
+				Counter: &prommodel.Counter{
+					Value: pfloat(42),
+					Exemplar: &prommodel.Exemplar{
+						Label:     []*prommodel.LabelPair{{Name: pstr("trace_id"), Value: pstr("abc")}},
+						Value:     pfloat(1),
+						Timestamp: timestamppb.New(ts),
+					},
+				},
+				TimestampMs: func() *int64 { ms := ts.UnixMilli(); return &ms }(),
+			},
+			{
+				Label:   []*prommodel.LabelPair{{Name: pstr("code"), Value: pstr("404")}},
+				Counter: &prommodel.Counter{Value: pfloat(1)},
+			},
+		},
+	}
+
+	histogramFamily := &prommodel.MetricFamily{
+		Name: pstr("foo_seconds"),
+		Type: prommodel.MetricType_HISTOGRAM.Enum(),
+		Metric: []*prommodel.Metric{
+			{
+				Histogram: &prommodel.Histogram{
+					Bucket: []*prommodel.Bucket{
+						{UpperBound: pfloat(0.1), CumulativeCount: puint64(1)},
+						{
+							UpperBound:      pfloat(1),
+							CumulativeCount: puint64(2),
+							Exemplar: &prommodel.Exemplar{
+								Label: []*prommodel.LabelPair{{Name: pstr("trace_id"), Value: pstr("def")}},
+								Value: pfloat(0.5),
+							},
+						},
+					},
+					Exemplars: []*prommodel.Exemplar{
+						{
+							Label: []*prommodel.LabelPair{{Name: pstr("trace_id"), Value: pstr("ghi")}},
+							Value: pfloat(0.05),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	gaugeFamily := &prommodel.MetricFamily{
+		Name:   pstr("foo"),
+		Type:   prommodel.MetricType_GAUGE.Enum(),
+		Metric: []*prommodel.Metric{{Gauge: &prommodel.Gauge{Value: pfloat(0)}}},
+	}
+
+	It("rejects unsupported exemplar property matchers", func() {
+		Expect(func() { HaveExemplar(HaveName("foo")) }).To(PanicWith(
+			ContainSubstring("unsupported exemplar property matcher")))
+	})
+
+	DescribeTable("GomegaString",
+		func(m MetricPropertyMatcher, expected string) {
+			gs, ok := m.(format.GomegaStringer)
+			Expect(ok).To(BeTrue(), "not a GomegaStringer: %T", m)
+			Expect(gs.GomegaString()).To(MatchRegexp(expected))
+		},
+		Entry("any exemplar", HaveExemplar(), `^exemplar$`),
+		Entry("exemplar", HaveExemplar(HaveLabel("trace_id=abc"), HaveSampleValue(1), HaveTimestamp(ts)),
+			`^exemplar
+.*label \{trace_id=abc\}
+.*value: 1
+.*timestamp: 2025-04-01T12:00:00Z$`),
+		Entry("timestamp matcher", HaveTimestamp(BeTemporally(">", ts)),
+			`^timestamp: .*BeTemporallyMatcher`),
+	)
+
+	DescribeTable("rejecting invalid expected values",
+		func(m MetricPropertyMatcher, expected string) {
+			Expect(m.(individualMetricMatcher).matchMetric(counterFamily, counterFamily.Metric[0])).
+				Error().To(MatchError(ContainSubstring(expected)))
+		},
+		Entry("exemplar value", HaveExemplar(HaveSampleValue("1")), "to be either a number or GomegaMatcher"),
+		Entry("exemplar timestamp", HaveExemplar(HaveTimestamp(42)), "to be either a time.Time or GomegaMatcher"),
+		Entry("exemplar label", HaveExemplar(HaveLabel(42)), "name matcher must not be <nil>"),
+		Entry("timestamp", HaveTimestamp(42), "to be either a time.Time or GomegaMatcher"),
+	)
+
+	DescribeTable("matching exemplars",
+		func(mf *prommodel.MetricFamily, m MetricPropertyMatcher, matchExpectations types.GomegaMatcher) {
+			Expect(m.(individualMetricMatcher).matchMetric(mf, mf.Metric[0])).To(matchExpectations)
+		},
+		Entry("any counter exemplar", counterFamily, HaveExemplar(), BeTrue()),
+		Entry("counter exemplar", counterFamily,
+			HaveExemplar(HaveLabel("trace_id=abc"), HaveSampleValue(1), HaveTimestamp(ts)), BeTrue()),
+		Entry("counter exemplar label matcher", counterFamily,
+			HaveExemplar(HaveLabelWithValue("trace_id", HavePrefix("a"))), BeTrue()),
+		Entry("wrong counter exemplar label", counterFamily,
+			HaveExemplar(HaveLabel("trace_id=def")), BeFalse()),
+		Entry("wrong counter exemplar value", counterFamily,
+			HaveExemplar(HaveSampleValue(2)), BeFalse()),
+		Entry("wrong counter exemplar timestamp", counterFamily,
+			HaveExemplar(HaveTimestamp(BeTemporally(">", ts))), BeFalse()),
+		Entry("bucket exemplar", histogramFamily,
+			HaveExemplar(HaveLabel("trace_id=def"), HaveSampleValue(0.5)), BeTrue()),
+		Entry("native exemplar", histogramFamily,
+			HaveExemplar(HaveLabel("trace_id=ghi")), BeTrue()),
+		Entry("missing exemplar timestamp", histogramFamily,
+			HaveExemplar(HaveLabel("trace_id=ghi"), HaveTimestamp(ts)), BeFalse()),
+		Entry("no gauge exemplar", gaugeFamily, HaveExemplar(), BeFalse()),
+		Entry("metric timestamp", counterFamily, HaveTimestamp(ts), BeTrue()),
+		Entry("missing metric timestamp", gaugeFamily, HaveTimestamp(ts), BeFalse()),
+	)
+
+	It("matches the exemplar on the same metric as the labels", func() {
+		Expect(Counter(HaveLabel("code=200"), HaveExemplar()).match(counterFamily)).To(BeTrue())
+		Expect(Counter(HaveLabel("code=404"), HaveExemplar()).match(counterFamily)).To(BeFalse())
+	})
+
+	It("reasons about collected exemplars", func() {
+		c := prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "foo_total",
+			Help: "foo.",
+		})
+		c.(prometheus.ExemplarAdder).AddWithExemplar(42, prometheus.Labels{"trace_id": "abc"})
+		h := prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "foo_duration_seconds",
+			Help:    "foo duration.",
+			Buckets: []float64{0.1, 1},
+		})
+		h.(prometheus.ExemplarObserver).ObserveWithExemplar(0.5, prometheus.Labels{"trace_id": "def"})
+		reg := prometheus.NewPedanticRegistry()
+		Expect(reg.Register(c)).To(Succeed())
+		Expect(reg.Register(h)).To(Succeed())
+		Expect(GatherAndLint(reg)).To(ContainMetrics(
+			Counter(HaveName("foo_total"),
+				HaveExemplar(HaveLabel("trace_id=abc"),
+					HaveSampleValue(42),
+					HaveTimestamp(BeTemporally("~", time.Now(), time.Minute)))),
+			Histogram(HaveName("foo_duration_seconds"),
+				HaveExemplar(HaveLabel("trace_id=def"), HaveSampleValue(0.5))),
+		))
+	})
+
+})
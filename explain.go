@@ -0,0 +1,118 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/onsi/gomega/format"
+	prommodel "github.com/prometheus/client_model/go"
+)
+
+// maxExplainedMetrics limits the number of individual metrics explained in
+// detail for a single metric family.
+const maxExplainedMetrics = 10
+
+// mismatchReasoner is optionally implemented by metric family property, label,
+// and individual metric property matchers in order to explain why they didn't
+// match, such as by describing the actual value found instead. For metric
+// family property matchers, the individual metric passed in is always nil.
+type mismatchReasoner interface {
+	mismatchReason(mf *prommodel.MetricFamily, metric *prommodel.Metric) string
+}
+
+// explain returns a checklist of the expected properties of this matcher,
+// marking each property as either matching (“✓”) or mismatching (“✗”) the
+// passed metric family. Additionally, it lists the expected labels and
+// individual metric properties for each metric of the metric family.
+func (m *TypedMetricFamilyMatcher) explain(mf *prommodel.MetricFamily) string {
+	var s strings.Builder
+	if m.anyType {
+		writeCheck(&s, 0, true, "type: ANY", "")
+	} else {
+		writeCheck(&s, 0, mf.GetType() == m.typ, "type: "+m.typ.String(),
+			"got "+mf.GetType().String())
+	}
+	if m.plainName != "" {
+		writeCheck(&s, 0, mf.GetName() == m.plainName, "name: "+m.plainName,
+			fmt.Sprintf("got %q", mf.GetName()))
+	}
+	for _, pm := range m.propertyMatchers {
+		success, err := pm.matchProperty(mf)
+		writeCheck(&s, 0, success && err == nil, gomegaString(pm), reason(pm, mf, nil, err))
+	}
+	if len(m.labelMatchers) == 0 && len(m.absentMatchers) == 0 && len(m.metricMatchers) == 0 {
+		return strings.TrimPrefix(s.String(), "\n")
+	}
+	metrics := mf.GetMetric()
+	if len(metrics) == 0 {
+		s.WriteString("\n✗ metrics: none")
+	}
+	for idx, metric := range metrics {
+		if idx == maxExplainedMetrics {
+			fmt.Fprintf(&s, "\n...and %d more metrics", len(metrics)-maxExplainedMetrics)
+			break
+		}
+		fmt.Fprintf(&s, "\nmetric %s:", labelsString(metric.GetLabel()))
+		for _, lm := range m.labelMatchers {
+			success, err := matchAllLabels(metric.GetLabel(), []metricLabelMatcher{lm})
+			writeCheck(&s, 1, success && err == nil, gomegaString(lm), reason(lm, mf, metric, err))
+		}
+		for _, am := range m.absentMatchers {
+			success, err := am.matchAbsentLabel(metric.GetLabel())
+			writeCheck(&s, 1, success && err == nil, gomegaString(am), reason(am, mf, metric, err))
+		}
+		for _, mm := range m.metricMatchers {
+			success, err := mm.matchMetric(mf, metric)
+			writeCheck(&s, 1, success && err == nil, firstLine(gomegaString(mm)), reason(mm, mf, metric, err))
+		}
+	}
+	return strings.TrimPrefix(s.String(), "\n")
+}
+
+// reason returns the reason for a mismatch of the passed matcher, if known;
+// an error always takes precedence.
+func reason(m any, mf *prommodel.MetricFamily, metric *prommodel.Metric, err error) string {
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	if r, ok := m.(mismatchReasoner); ok {
+		return r.mismatchReason(mf, metric)
+	}
+	return ""
+}
+
+// writeCheck writes a single checklist item at the specified indentation level,
+// consisting of the check mark and the expectation. For mismatches, it
+// additionally writes the mismatch reason, if any: either inline for
+// single-line expectations, or on its own line otherwise.
+func writeCheck(s *strings.Builder, indentation uint, success bool, expected, reason string) {
+	mark := "✗"
+	if success {
+		mark = "✓"
+	}
+	s.WriteRune('\n')
+	s.WriteString(format.IndentString(mark+" "+expected, indentation))
+	if success || reason == "" {
+		return
+	}
+	if strings.Contains(expected, "\n") {
+		s.WriteRune('\n')
+		s.WriteString(format.IndentString(reason, indentation+1))
+		return
+	}
+	s.WriteString(", " + reason)
+}
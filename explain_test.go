@@ -0,0 +1,131 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"time"
+
+	prommodel "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("explaining mismatches", func() {
+
+	nativeFamily := &prommodel.MetricFamily{
+		Name: pstr("foo_seconds"),
+		Type: prommodel.MetricType_HISTOGRAM.Enum(),
+		Metric: []*prommodel.Metric{{
+			Histogram: &prommodel.Histogram{
+				SampleCount:   puint64(3),
+				Schema:        pint32(3),
+				ZeroThreshold: pfloat(0.001),
+				ZeroCount:     puint64(1),
+				PositiveSpan: []*prommodel.BucketSpan{
+					{Offset: pint32(0), Length: puint32(1)},
+				},
+				PositiveDelta: []int64{2},
+			},
+		}},
+	}
+
+	classicFamily := &prommodel.MetricFamily{
+		Name: pstr("foo_seconds"),
+		Type: prommodel.MetricType_HISTOGRAM.Enum(),
+		Metric: []*prommodel.Metric{{
+			Histogram: &prommodel.Histogram{
+				SampleCount: puint64(1),
+				Bucket: []*prommodel.Bucket{
+					{UpperBound: pfloat(1), CumulativeCount: puint64(1)},
+				},
+			},
+		}},
+	}
+
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	exemplarFamily := &prommodel.MetricFamily{
+		Name: pstr("foo_total"),
+		Type: prommodel.MetricType_COUNTER.Enum(),
+		Metric: []*prommodel.Metric{{
+			Counter: &prommodel.Counter{
+				Value: pfloat(2),
+				Exemplar: &prommodel.Exemplar{
+					Label:     []*prommodel.LabelPair{{Name: pstr("trace_id"), Value: pstr("abc")}},
+					Value:     pfloat(1.5),
+					Timestamp: timestamppb.New(ts),
+				},
+			},
+		}},
+	}
+
+	gaugeFamily := &prommodel.MetricFamily{
+		Name:   pstr("foo"),
+		Type:   prommodel.MetricType_GAUGE.Enum(),
+		Metric: []*prommodel.Metric{{Gauge: &prommodel.Gauge{Value: pfloat(0)}}},
+	}
+
+	DescribeTable("mismatch reasons",
+		func(m MetricPropertyMatcher, mf *prommodel.MetricFamily, expected string) {
+			Expect(m.(mismatchReasoner).mismatchReason(mf, mf.Metric[0])).To(Equal(expected))
+		},
+		Entry("histogram kind", HaveHistogramKind(ClassicHistogram), nativeFamily, "got native histogram"),
+		Entry("histogram kind of non-histogram", HaveHistogramKind(ClassicHistogram), gaugeFamily, "no histogram kind for GAUGE"),
+		Entry("schema", HaveSchema(0), nativeFamily, "got schema 3"),
+		Entry("zero threshold", HaveZeroThreshold(0.1), nativeFamily, "got zero threshold 0.001"),
+		Entry("zero count", HaveZeroCount(0), nativeFamily, "got zero count 1"),
+		Entry("schema of classic histogram", HaveSchema(0), classicFamily, "not a native histogram"),
+		Entry("exemplar", HaveExemplar(HaveLabel("trace_id=xyz")), exemplarFamily,
+			`got exemplar value 1.5 with labels {trace_id="abc"} at 2025-01-02T03:04:05Z`),
+		Entry("missing exemplar", HaveExemplar(), gaugeFamily, "no exemplars"),
+		Entry("increase", HaveIncreasedBy(1), exemplarFamily, "increased by 2"),
+		Entry("increase of histogram", HaveIncreasedBy(1), nativeFamily, "increased by 3"),
+		Entry("increase of unknown type", HaveIncreasedBy(1),
+			&prommodel.MetricFamily{
+				Type:   prommodel.MetricType(42).Enum(),
+				Metric: []*prommodel.Metric{{}},
+			}, "no increase for 42"),
+	)
+
+	It("explains native histogram mismatches in the checklist", func() {
+		m := BeAMetric(Histogram(
+			HaveHistogramKind(ClassicHistogram),
+			HaveSchema(0),
+			HaveZeroCount(0)))
+		Expect(m.Match(nativeFamily)).To(BeFalse())
+		Expect(m.FailureMessage(nativeFamily)).To(HaveSuffix(`
+checklist:
+    ✓ type: HISTOGRAM
+    metric {}:
+        ✗ histogram kind: classic, got native histogram
+        ✗ schema: 0, got schema 3
+        ✗ zero count: 0, got zero count 1`))
+	})
+
+	It("explains exemplar and increase mismatches in the checklist", func() {
+		m := BeAMetric(Counter(
+			HaveExemplar(HaveLabel("trace_id=xyz")),
+			HaveIncreasedBy(1)))
+		Expect(m.Match(exemplarFamily)).To(BeFalse())
+		Expect(m.FailureMessage(exemplarFamily)).To(HaveSuffix(`
+checklist:
+    ✓ type: COUNTER
+    metric {}:
+        ✗ exemplar, got exemplar value 1.5 with labels {trace_id="abc"} at 2025-01-02T03:04:05Z
+        ✗ increased by: 1, increased by 2`))
+	})
+
+})
@@ -45,14 +45,32 @@ type metricLabelMatcher interface {
 	matchLabel(*prommodel.LabelPair) (bool, error)
 }
 
+// metricAbsentLabelMatcher succeeds if none of the actual labels of an
+// individual metric match the specified name and optionally the specified
+// value.
+type metricAbsentLabelMatcher interface {
+	matchAbsentLabel([]*prommodel.LabelPair) (bool, error)
+}
+
+// individualMetricMatcher succeeds if a property of an individual metric
+// matches, such as its sample value. As the interpretation of an individual
+// metric depends on the type of its metric family, the metric family is passed
+// in too.
+type individualMetricMatcher interface {
+	matchMetric(*prommodel.MetricFamily, *prommodel.Metric) (bool, error)
+}
+
 // TypedMetricFamilyMatcher implements MetricMatcher to match metrics within a
 // metric family that satisfy a mandatory type, optional name, optional
 // properties other than name and labels, and finally a set of labels.
 type TypedMetricFamilyMatcher struct {
-	plainName        string                  // non-zero if plain string to match, otherwise "".
-	typ              prommodel.MetricType    // type of metric, such as counter, gauge, ...
-	propertyMatchers []metricPropertyMatcher // the metric and metric family properties to match.
-	labelMatchers    []metricLabelMatcher    // metric labels that must be all matched on the same metric.
+	plainName        string                     // non-zero if plain string to match, otherwise "".
+	typ              prommodel.MetricType       // type of metric, such as counter, gauge, ...
+	anyType          bool                       // if true, match any metric type and ignore typ.
+	propertyMatchers []metricPropertyMatcher    // the metric and metric family properties to match.
+	labelMatchers    []metricLabelMatcher       // metric labels that must be all matched on the same metric.
+	absentMatchers   []metricAbsentLabelMatcher // metric labels that must all be absent from the same metric.
+	metricMatchers   []individualMetricMatcher  // individual metric properties to match on the same metric as the labels.
 }
 
 var (
@@ -64,11 +82,19 @@ var (
 // reporting, reducing visual clutter compared to simply dumbing the matcher
 // using Gomega's format.Object.
 func (m *TypedMetricFamilyMatcher) GomegaString() string {
-	return fmt.Sprintf("\n%s:%s%s%s",
-		m.typ.String(),
+	return fmt.Sprintf("\n%s:%s%s%s%s",
+		m.expectedType(),
 		m.expectedName(),
 		m.expectedProperties(),
-		m.expectedLabels())
+		m.expectedLabels(),
+		m.expectedMetricProperties())
+}
+
+func (m *TypedMetricFamilyMatcher) expectedType() string {
+	if m.anyType {
+		return "ANY"
+	}
+	return m.typ.String()
 }
 
 func (m *TypedMetricFamilyMatcher) expectedName() string {
@@ -93,7 +119,7 @@ func (m *TypedMetricFamilyMatcher) expectedProperties() string {
 }
 
 func (m *TypedMetricFamilyMatcher) expectedLabels() string {
-	if len(m.labelMatchers) == 0 {
+	if len(m.labelMatchers) == 0 && len(m.absentMatchers) == 0 {
 		return ""
 	}
 	var s strings.Builder
@@ -101,6 +127,22 @@ func (m *TypedMetricFamilyMatcher) expectedLabels() string {
 		s.WriteRune('\n')
 		s.WriteString(format.IndentString(label.(format.GomegaStringer).GomegaString(), 1))
 	}
+	for _, label := range m.absentMatchers {
+		s.WriteRune('\n')
+		s.WriteString(format.IndentString(label.(format.GomegaStringer).GomegaString(), 1))
+	}
+	return s.String()
+}
+
+func (m *TypedMetricFamilyMatcher) expectedMetricProperties() string {
+	if len(m.metricMatchers) == 0 {
+		return ""
+	}
+	var s strings.Builder
+	for _, metricMatcher := range m.metricMatchers {
+		s.WriteRune('\n')
+		s.WriteString(format.IndentString(metricMatcher.(format.GomegaStringer).GomegaString(), 1))
+	}
 	return s.String()
 }
 
@@ -117,6 +159,10 @@ func metricOfType(mettype prommodel.MetricType, props ...MetricPropertyMatcher)
 	//    later match directly to the plain string name. If it doesn't match on a
 	//    plain name then instead keep it as a normal metric (family) property matcher.
 	//  - if it's a labelMatcher then put it into its separate list of label matchers.
+	//  - if it's an absent labelMatcher then put it into its separate list of
+	//    absent label matchers.
+	//  - if it's an individualMetricMatcher then put it into its separate list
+	//    of individual metric matchers.
 	//  - everything else is "just" a metric (family) property matcher.
 	for _, propm := range props {
 		switch matcher := propm.(type) {
@@ -133,6 +179,10 @@ func metricOfType(mettype prommodel.MetricType, props ...MetricPropertyMatcher)
 			m.propertyMatchers = append(m.propertyMatchers, matcher)
 		case metricLabelMatcher:
 			m.labelMatchers = append(m.labelMatchers, matcher)
+		case metricAbsentLabelMatcher:
+			m.absentMatchers = append(m.absentMatchers, matcher)
+		case individualMetricMatcher:
+			m.metricMatchers = append(m.metricMatchers, matcher)
 		default:
 			panic(fmt.Sprintf("internal error: unsupported MetricProperyMatcher of type %T", propm))
 		}
@@ -141,13 +191,54 @@ func metricOfType(mettype prommodel.MetricType, props ...MetricPropertyMatcher)
 }
 
 // match succeeds if the passed MetricFamily...
-//   - matches the expected metric type,
+//   - matches the expected metric type, unless any type is acceptable,
 //   - matches the expected plain name, if specified,
 //   - matches all expected metric family properties (including the name in case of
 //     complex name matching).
-//   - matches all expected labels within any, but same, metric of this family.
+//   - matches all expected labels, doesn't match any absent labels, and
+//     matches all individual metric properties (such as the sample value)
+//     within any, but same, metric of this family.
 func (m *TypedMetricFamilyMatcher) match(metfam *prommodel.MetricFamily) (bool, error) {
-	if metfam.GetType() != m.typ {
+	success, err := m.matchFamily(metfam)
+	if err != nil || !success {
+		return false, err
+	}
+	// nota bene: on a valid metric family we always have at least one metric;
+	// if the test doesn't care about labels and individual metric properties at
+	// all, we can shortcut things here.
+	if len(m.labelMatchers) == 0 && len(m.absentMatchers) == 0 && len(m.metricMatchers) == 0 {
+		return true, nil
+	}
+	for _, metric := range metfam.GetMetric() {
+		success, err := m.matchSingleMetric(metfam, metric)
+		if err != nil {
+			return false, err
+		}
+		if success {
+			// finally, we passed all exams!!!
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// matchIndividual succeeds if the passed MetricFamily matches the expected
+// metric type, name, and metric family properties, and the passed individual
+// metric of this family matches the expected labels, absent labels, and
+// individual metric properties. In contrast to match, metric family properties,
+// such as the timeseries count, always see the complete metric family.
+func (m *TypedMetricFamilyMatcher) matchIndividual(metfam *prommodel.MetricFamily, metric *prommodel.Metric) (bool, error) {
+	success, err := m.matchFamily(metfam)
+	if err != nil || !success {
+		return false, err
+	}
+	return m.matchSingleMetric(metfam, metric)
+}
+
+// matchFamily succeeds if the passed MetricFamily matches the expected metric
+// type, plain name, and all expected metric family properties.
+func (m *TypedMetricFamilyMatcher) matchFamily(metfam *prommodel.MetricFamily) (bool, error) {
+	if !m.anyType && metfam.GetType() != m.typ {
 		return false, nil
 	}
 	if m.plainName != "" && m.plainName != metfam.GetName() {
@@ -162,22 +253,38 @@ func (m *TypedMetricFamilyMatcher) match(metfam *prommodel.MetricFamily) (bool,
 			return false, nil
 		}
 	}
-	// nota bene: on a valid metric family we always have at least one metric;
-	// if the test doesn't care about labels at all, we can shortcut things here.
-	if len(m.labelMatchers) == 0 {
-		return true, nil
+	return true, nil
+}
+
+// matchSingleMetric succeeds if the passed individual metric of the specified
+// MetricFamily matches all expected labels, doesn't match any absent labels,
+// and matches all individual metric properties.
+func (m *TypedMetricFamilyMatcher) matchSingleMetric(metfam *prommodel.MetricFamily, metric *prommodel.Metric) (bool, error) {
+	success, err := matchAllLabels(metric.GetLabel(), m.labelMatchers)
+	if err != nil || !success {
+		return false, err
 	}
-	for _, metric := range metfam.GetMetric() {
-		success, err := matchAllLabels(metric.GetLabel(), m.labelMatchers)
+	success, err = matchAllAbsentLabels(metric.GetLabel(), m.absentMatchers)
+	if err != nil || !success {
+		return false, err
+	}
+	return matchAllMetricProperties(metfam, metric, m.metricMatchers)
+}
+
+// matchAllMetricProperties succeeds if all expected individual metric
+// properties match the passed metric. It returns an error as soon as any
+// underlying matcher returns an error.
+func matchAllMetricProperties(metfam *prommodel.MetricFamily, metric *prommodel.Metric, expected []individualMetricMatcher) (bool, error) {
+	for _, matcher := range expected {
+		success, err := matcher.matchMetric(metfam, metric)
 		if err != nil {
 			return false, err
 		}
-		if success {
-			// finally, we passed all exams!!!
-			return true, nil
+		if !success {
+			return false, nil
 		}
 	}
-	return false, nil
+	return true, nil
 }
 
 // indexname returns the plain string metrics name if explicitly specified,
@@ -43,6 +43,7 @@ var (
 	_ (MetricPropertyMatcher) = (*MetricFamilyNameMatcher)(nil)
 	_ (metricNamer)           = (*MetricFamilyNameMatcher)(nil)
 	_ (metricPropertyMatcher) = (*MetricFamilyNameMatcher)(nil)
+	_ (mismatchReasoner)      = (*MetricFamilyNameMatcher)(nil)
 	_ (format.GomegaStringer) = (*MetricFamilyNameMatcher)(nil)
 )
 
@@ -70,6 +71,10 @@ func (m *MetricFamilyNameMatcher) matchProperty(mf *prommodel.MetricFamily) (boo
 	return m.matcher.Match(mf.GetName())
 }
 
+func (m *MetricFamilyNameMatcher) mismatchReason(mf *prommodel.MetricFamily, _ *prommodel.Metric) string {
+	return fmt.Sprintf("got %q", mf.GetName())
+}
+
 // ----
 
 // MetricFamilyHelpMatcher matches the help property of a metric family.
@@ -81,6 +86,7 @@ type MetricFamilyHelpMatcher struct {
 var (
 	_ (MetricPropertyMatcher) = (*MetricFamilyHelpMatcher)(nil)
 	_ (metricPropertyMatcher) = (*MetricFamilyHelpMatcher)(nil)
+	_ (mismatchReasoner)      = (*MetricFamilyHelpMatcher)(nil)
 	_ (format.GomegaStringer) = (*MetricFamilyHelpMatcher)(nil)
 )
 
@@ -101,6 +107,10 @@ func (m *MetricFamilyHelpMatcher) matchProperty(mf *prommodel.MetricFamily) (boo
 	return m.matcher.Match(mf.GetHelp())
 }
 
+func (m *MetricFamilyHelpMatcher) mismatchReason(mf *prommodel.MetricFamily, _ *prommodel.Metric) string {
+	return fmt.Sprintf("got %q", mf.GetHelp())
+}
+
 // ----
 
 // MetricFamilyUnitMatcher matches the unit property of a metric family.
@@ -112,6 +122,7 @@ type MetricFamilyUnitMatcher struct {
 var (
 	_ (MetricPropertyMatcher) = (*MetricFamilyUnitMatcher)(nil)
 	_ (metricPropertyMatcher) = (*MetricFamilyUnitMatcher)(nil)
+	_ (mismatchReasoner)      = (*MetricFamilyUnitMatcher)(nil)
 	_ (format.GomegaStringer) = (*MetricFamilyUnitMatcher)(nil)
 )
 
@@ -131,3 +142,7 @@ func (m *MetricFamilyUnitMatcher) matchProperty(mf *prommodel.MetricFamily) (boo
 	}
 	return m.matcher.Match(mf.GetUnit())
 }
+
+func (m *MetricFamilyUnitMatcher) mismatchReason(mf *prommodel.MetricFamily, _ *prommodel.Metric) string {
+	return fmt.Sprintf("got %q", mf.GetUnit())
+}
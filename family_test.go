@@ -93,6 +93,27 @@ var _ = Describe("matching metrics in families", func() {
 		Entry("correct counter", Counter(), BeTrue()),
 		Entry("wrong gauge", Gauge(), BeFalse()),
 		Entry("wrong history", Histogram(), BeFalse()),
+		Entry("wrong summary", Summary(), BeFalse()),
+		Entry("wrong untyped", Untyped(), BeFalse()),
+		Entry("wrong gauge histogram", GaugeHistogram(), BeFalse()),
+		Entry("any", AnyMetric(), BeTrue()),
+		Entry("any with name", AnyMetric(HaveName("foo_bar_total")), BeTrue()),
+		Entry("any with wrong name", AnyMetric(HaveName("foo_bar")), BeFalse()),
+	)
+
+	DescribeTable("matching other metric types",
+		func(m MetricMatcher, typ prommodel.MetricType) {
+			Expect(m.match(&prommodel.MetricFamily{
+				Name:   pstr("foo"),
+				Type:   typ.Enum(),
+				Metric: []*prommodel.Metric{{}},
+			})).To(BeTrue())
+		},
+		Entry(nil, Summary(), prommodel.MetricType_SUMMARY),
+		Entry(nil, Untyped(), prommodel.MetricType_UNTYPED),
+		Entry(nil, GaugeHistogram(), prommodel.MetricType_GAUGE_HISTOGRAM),
+		Entry(nil, AnyMetric(), prommodel.MetricType_GAUGE_HISTOGRAM),
+		Entry(nil, AnyMetric(), prommodel.MetricType_SUMMARY),
 	)
 
 	DescribeTable("matching the metric unit",
@@ -150,6 +171,25 @@ var _ = Describe("matching metrics in families", func() {
 
 		It("reports metric label matcher errors", func() {
 			Expect(Counter(HaveLabel(BeTrue())).match(counterFamily)).Error().To(HaveOccurred())
+			Expect(Counter(NotHaveLabel(BeTrue())).match(counterFamily)).Error().To(HaveOccurred())
+		})
+
+		It("matches absent labels on the same metric", func() {
+			Expect(Counter(NotHaveLabel("foobar")).match(counterFamily)).To(BeTrue())
+			Expect(Counter(HaveLabel("foo=bar"), NotHaveLabel("foobar")).match(counterFamily)).To(BeTrue())
+			Expect(Counter(HaveLabel("foo=bar"), WithoutLabel("baz")).match(counterFamily)).To(BeFalse())
+			Expect(Counter(HaveLabel("foo=bar"), WithoutLabel("baz=booze")).match(counterFamily)).To(BeTrue())
+			Expect(Counter(HaveLabel("foobar"), WithoutLabel(HavePrefix("ba"))).match(counterFamily)).To(BeTrue())
+			Expect(Counter(WithoutLabel(HavePrefix("foo"))).match(counterFamily)).To(BeFalse())
+		})
+
+		It("reports absent labels", func() {
+			m := BeAMetric(Counter(HaveLabel("foo=bar"), NotHaveLabel("baz")))
+			Expect(m.Match(counterFamily)).To(BeFalse())
+			Expect(m.FailureMessage(counterFamily)).To(MatchRegexp(
+				`COUNTER:
+.*label \{foo=bar\}
+.*no label \{baz\}`))
 		})
 
 	})
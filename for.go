@@ -0,0 +1,185 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"io"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	gi "github.com/onsi/ginkgo/v2"
+	gom "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
+)
+
+// Tester provides the collecting, gathering, scraping, parsing, and linting
+// helpers of this package using a specific Gomega instance, instead of
+// Ginkgo's default Gomega, and optionally specific lint options and scrape
+// timeout. Create a Tester using [For], [LintWith], or [ScrapeWith].
+type Tester struct {
+	gomega        types.Gomega
+	lintOptions   *lintOptions
+	scrapeTimeout time.Duration
+}
+
+// For returns a [Tester] that uses the passed Gomega instance for its
+// assertions. This allows using the helpers of this package with plain “go
+// test” tests and without Ginkgo, such as:
+//
+//	func TestFoo(t *testing.T) {
+//	    g := gomega.NewWithT(t)
+//	    families := pyrotest.For(g).CollectAndLint(fooCollector)
+//	    g.Expect(families).To(pyrotest.ContainMetrics(...))
+//	}
+//
+// When passed a Gomega instance created using [gom.NewWithT], any test failure
+// is correctly attributed to the caller of the Tester's methods.
+func For(gomega types.Gomega) Tester {
+	return Tester{gomega: gomega}
+}
+
+// LintWith returns a [Tester] using Ginkgo's default Gomega that lints
+// metrics according to the passed lint options, such as:
+//
+//	LintWith(DisableLintRules(LintCounter),
+//	    SuppressLintProblemsMatching("legacy_.*")).
+//	    CollectAndLint(coll)
+func LintWith(opts ...LintOption) Tester {
+	return For(gom.Default).LintWith(opts...)
+}
+
+// LintWith returns a copy of this [Tester] that additionally applies the
+// passed lint options when linting metrics.
+func (t Tester) LintWith(opts ...LintOption) Tester {
+	t.lintOptions = newLintOptions(t.lintOptions, opts...)
+	return t
+}
+
+// ScrapeWith returns a [Tester] using Ginkgo's default Gomega that gives up
+// scraping metrics endpoints after the specified timeout, instead of
+// [DefaultScrapeTimeout], such as:
+//
+//	ScrapeWith(2 * time.Second).ScrapeAndLint(url)
+func ScrapeWith(timeout time.Duration) Tester {
+	return For(gom.Default).ScrapeWith(timeout)
+}
+
+// ScrapeWith returns a copy of this [Tester] that gives up scraping metrics
+// endpoints after the specified timeout.
+func (t Tester) ScrapeWith(timeout time.Duration) Tester {
+	t.scrapeTimeout = timeout
+	return t
+}
+
+// CollectAndLint works like the package-level [CollectAndLint], but uses the
+// Tester's Gomega instance.
+func (t Tester) CollectAndLint(coll prometheus.Collector, metricNames ...string) MetricsFamilies {
+	gi.GinkgoHelper()
+	THelper(t.gomega)()
+	return collectAndLint(t.gomega, t.lintOptions, coll, metricNames...)
+}
+
+// GatherAndLint works like the package-level [GatherAndLint], but uses the
+// Tester's Gomega instance.
+func (t Tester) GatherAndLint(g prometheus.Gatherer, metricNames ...string) MetricsFamilies {
+	gi.GinkgoHelper()
+	THelper(t.gomega)()
+	return gatherAndLint(t.gomega, t.lintOptions, g, metricNames...)
+}
+
+// Gather works like the package-level [Gather], but applies the Tester's lint
+// options. As the returned function never fails the current test, the Tester's
+// Gomega instance isn't used.
+func (t Tester) Gather(g prometheus.Gatherer, metricNames ...string) func() (MetricsFamilies, error) {
+	return gather(t.lintOptions, g, metricNames...)
+}
+
+// ScrapeAndLint works like the package-level [ScrapeAndLint], but uses the
+// Tester's Gomega instance and scrape timeout.
+func (t Tester) ScrapeAndLint(url string, metricNames ...string) MetricsFamilies {
+	gi.GinkgoHelper()
+	THelper(t.gomega)()
+	return scrapeAndLint(t.gomega, t.lintOptions, t.scrapeTimeout, url, metricNames...)
+}
+
+// ParseText works like the package-level [ParseText], but uses the Tester's
+// Gomega instance.
+func (t Tester) ParseText(r io.Reader) MetricsFamilies {
+	gi.GinkgoHelper()
+	THelper(t.gomega)()
+	return parseText(t.gomega, r, "")
+}
+
+// ParseTextFile works like the package-level [ParseTextFile], but uses the
+// Tester's Gomega instance.
+func (t Tester) ParseTextFile(path string) MetricsFamilies {
+	gi.GinkgoHelper()
+	THelper(t.gomega)()
+	return parseTextFile(t.gomega, path)
+}
+
+// ParseTextAndLint works like the package-level [ParseTextAndLint], but uses
+// the Tester's Gomega instance.
+func (t Tester) ParseTextAndLint(r io.Reader, metricNames ...string) MetricsFamilies {
+	gi.GinkgoHelper()
+	THelper(t.gomega)()
+	return parseTextAndLint(t.gomega, t.lintOptions, r, "", metricNames...)
+}
+
+// ParseTextFileAndLint works like the package-level [ParseTextFileAndLint],
+// but uses the Tester's Gomega instance.
+func (t Tester) ParseTextFileAndLint(path string, metricNames ...string) MetricsFamilies {
+	gi.GinkgoHelper()
+	THelper(t.gomega)()
+	return parseTextFileAndLint(t.gomega, t.lintOptions, path, metricNames...)
+}
+
+// TakeSnapshot works like the package-level [TakeSnapshot], but uses the
+// Tester's Gomega instance.
+func (t Tester) TakeSnapshot(g prometheus.Gatherer) Snapshot {
+	gi.GinkgoHelper()
+	THelper(t.gomega)()
+	return takeSnapshot(t.gomega, g)
+}
+
+// TakeCollectorSnapshot works like the package-level [TakeCollectorSnapshot],
+// but uses the Tester's Gomega instance.
+func (t Tester) TakeCollectorSnapshot(coll prometheus.Collector) Snapshot {
+	gi.GinkgoHelper()
+	THelper(t.gomega)()
+	return takeCollectorSnapshot(t.gomega, coll)
+}
+
+// VerifyCollector works like the package-level [VerifyCollector], but uses
+// the Tester's Gomega instance.
+func (t Tester) VerifyCollector(coll prometheus.Collector, opts ...VerifyOption) CollectorReport {
+	gi.GinkgoHelper()
+	THelper(t.gomega)()
+	return verifyCollector(t.gomega, coll, opts...)
+}
+
+// THelper returns the testing.T Helper function of the passed Gomega instance
+// if it has been created using [gom.NewWithT], otherwise a no-op function. The
+// returned function must be called directly by the function that is to be
+// marked as a test helper, as in “THelper(gomega)()”. Packages building on
+// pyrotest, such as its promql sub-module, use THelper for their own helpers
+// accepting a Gomega instance.
+func THelper(gomega types.Gomega) func() {
+	if withT, ok := gomega.(*gom.WithT); ok && withT.THelper != nil {
+		return withT.THelper
+	}
+	return func() {}
+}
@@ -0,0 +1,97 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// fakeT records the failures and helper calls of a Gomega created using
+// NewWithT.
+type fakeT struct {
+	helpers  int
+	failures []string
+}
+
+func (t *fakeT) Helper() { t.helpers++ }
+
+func (t *fakeT) Fatalf(format string, args ...any) {
+	t.failures = append(t.failures, fmt.Sprintf(format, args...))
+}
+
+// TestFor exercises the Tester using plain “go test” and NewWithT, outside
+// Ginkgo specs.
+func TestFor(t *testing.T) {
+	g := NewWithT(t)
+	c := prometheus.NewCounter(prometheus.CounterOpts{Name: "foo_total", Help: "foo."})
+	c.Inc()
+	g.Expect(For(g).CollectAndLint(c)).To(ConsistOfMetrics(
+		Counter(HaveName("foo_total"), HaveSampleValue(1))))
+
+	before := For(g).TakeCollectorSnapshot(c)
+	c.Inc()
+	g.Expect(Delta(before, For(g).TakeCollectorSnapshot(c))).To(ContainMetrics(
+		Counter(HaveName("foo_total"), HaveIncreasedBy(1))))
+}
+
+var _ = Describe("testers for specific Gomega instances", func() {
+
+	It("works with Ginkgo's default Gomega", func() {
+		reg := prometheus.NewPedanticRegistry()
+		c := prometheus.NewCounter(prometheus.CounterOpts{Name: "foo_total", Help: "foo."})
+		Expect(reg.Register(c)).To(Succeed())
+		tester := For(Default)
+
+		Expect(tester.CollectAndLint(c)).To(HaveLen(1))
+		Expect(tester.GatherAndLint(reg)).To(HaveLen(1))
+		Expect(Delta(tester.TakeSnapshot(reg), tester.TakeSnapshot(reg))).To(ContainMetrics(
+			Counter(HaveName("foo_total"), HaveIncreasedBy(0))))
+
+		srv := httptest.NewServer(staticHandler(http.StatusOK, "text/plain", textExposition))
+		defer srv.Close()
+		Expect(tester.ScrapeAndLint(srv.URL, "bar")).To(HaveLen(1))
+
+		Expect(tester.ParseText(strings.NewReader(textExposition))).To(HaveLen(2))
+		Expect(tester.ParseTextAndLint(strings.NewReader(textExposition))).To(HaveLen(2))
+		path := filepath.Join(GinkgoT().TempDir(), "metrics.prom")
+		Expect(os.WriteFile(path, []byte(textExposition), 0o644)).To(Succeed())
+		Expect(tester.ParseTextFile(path)).To(HaveLen(2))
+		Expect(tester.ParseTextFileAndLint(path)).To(HaveLen(2))
+	})
+
+	It("reports failures to testing.T and marks helpers", func() {
+		t := &fakeT{}
+		For(NewWithT(t)).CollectAndLint(&fishyCollector{})
+		Expect(t.failures).To(ConsistOf(ContainSubstring("counter metrics should have")))
+		Expect(t.helpers).To(BeNumerically(">=", 3))
+	})
+
+	It("returns no-op helpers for non-testing.T Gomegas", func() {
+		Expect(THelper(Default)).NotTo(BeNil())
+		THelper(Default)()
+	})
+
+})
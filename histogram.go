@@ -0,0 +1,221 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+	prommodel "github.com/prometheus/client_model/go"
+)
+
+// isHistogramType returns true if the passed metric type is either a
+// (classic/native) histogram or a gauge histogram.
+func isHistogramType(typ prommodel.MetricType) bool {
+	return typ == prommodel.MetricType_HISTOGRAM || typ == prommodel.MetricType_GAUGE_HISTOGRAM
+}
+
+// histogramLayout returns a concise textual representation of the bucket
+// layout of a histogram metric, including the sample count and sample sum. For
+// native and hybrid histograms, the layout additionally includes the native
+// histogram properties and decoded native buckets.
+func histogramLayout(metric *prommodel.Metric) string {
+	h := metric.GetHistogram()
+	var s strings.Builder
+	kind := histogramKind(h)
+	if kind != NativeHistogram {
+		s.WriteRune('[')
+		for idx, bucket := range h.GetBucket() {
+			if idx > 0 {
+				s.WriteString(", ")
+			}
+			fmt.Fprintf(&s, "le=%s: %s",
+				FormatFloat(bucket.GetUpperBound()), FormatFloat(bucketCount(bucket)))
+		}
+		s.WriteString("] ")
+	}
+	fmt.Fprintf(&s, "count: %s, sum: %s",
+		FormatFloat(histogramSampleCount(h)), FormatFloat(h.GetSampleSum()))
+	if kind != ClassicHistogram {
+		s.WriteString(", " + nativeHistogramLayout(h))
+	}
+	return s.String()
+}
+
+// labelsString returns the passed labels in their usual “{name="value",...}”
+// textual representation.
+func labelsString(labels []*prommodel.LabelPair) string {
+	var s strings.Builder
+	s.WriteRune('{')
+	for idx, label := range labels {
+		if idx > 0 {
+			s.WriteRune(',')
+		}
+		fmt.Fprintf(&s, "%s=%q", label.GetName(), label.GetValue())
+	}
+	s.WriteRune('}')
+	return s.String()
+}
+
+// FormatFloat returns the shortest representation of a float64 value, using
+// the Prometheus “+Inf” and “-Inf” notation for infinite values, as used in
+// “le” and “quantile” label values.
+func FormatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, +1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// histogramSampleCount returns the sample count of a histogram as a float64,
+// regardless of it being an integer or float histogram.
+func histogramSampleCount(h *prommodel.Histogram) float64 {
+	if h.SampleCountFloat != nil {
+		return h.GetSampleCountFloat()
+	}
+	return float64(h.GetSampleCount())
+}
+
+// bucketCount returns the cumulative count of a classic histogram bucket as a
+// float64, regardless of it being an integer or float bucket.
+func bucketCount(b *prommodel.Bucket) float64 {
+	if b.CumulativeCountFloat != nil {
+		return b.GetCumulativeCountFloat()
+	}
+	return float64(b.GetCumulativeCount())
+}
+
+// ----
+
+// HistogramBucketMatcher matches a classic histogram bucket with a specific
+// upper bound and its cumulative count.
+type HistogramBucketMatcher struct {
+	upperBound float64
+	matcher    types.GomegaMatcher
+	expected   any // original expected cumulative count for error reporting.
+}
+
+var (
+	_ (MetricPropertyMatcher)   = (*HistogramBucketMatcher)(nil)
+	_ (individualMetricMatcher) = (*HistogramBucketMatcher)(nil)
+	_ (mismatchReasoner)        = (*HistogramBucketMatcher)(nil)
+	_ (format.GomegaStringer)   = (*HistogramBucketMatcher)(nil)
+)
+
+func (m *HistogramBucketMatcher) GomegaString() string {
+	return fmt.Sprintf("bucket {le=%s}: %s",
+		FormatFloat(m.upperBound), numberString(m.expected))
+}
+
+func (m *HistogramBucketMatcher) yesimametricpropertymatcher() {}
+
+// matchMetric succeeds if the histogram of the passed metric has a bucket with
+// the expected upper bound and a matching cumulative count.
+func (m *HistogramBucketMatcher) matchMetric(mf *prommodel.MetricFamily, metric *prommodel.Metric) (bool, error) {
+	if m.matcher == nil {
+		return false, errors.New(format.Message(
+			m.expected, "to be either a number or GomegaMatcher"))
+	}
+	if !isHistogramType(mf.GetType()) {
+		return false, nil
+	}
+	for _, bucket := range metric.GetHistogram().GetBucket() {
+		if bucket.GetUpperBound() != m.upperBound {
+			continue
+		}
+		success, err := m.matcher.Match(bucketCount(bucket))
+		if err != nil {
+			return false, err
+		}
+		if success {
+			return true, nil
+		}
+		break
+	}
+	return false, nil
+}
+
+func (m *HistogramBucketMatcher) mismatchReason(mf *prommodel.MetricFamily, metric *prommodel.Metric) string {
+	return histogramMismatchReason(mf, metric)
+}
+
+// ----
+
+// HistogramBucketBoundsMatcher matches the upper bounds of all buckets of a
+// classic histogram.
+type HistogramBucketBoundsMatcher struct {
+	bounds []float64
+}
+
+var (
+	_ (MetricPropertyMatcher)   = (*HistogramBucketBoundsMatcher)(nil)
+	_ (individualMetricMatcher) = (*HistogramBucketBoundsMatcher)(nil)
+	_ (mismatchReasoner)        = (*HistogramBucketBoundsMatcher)(nil)
+	_ (format.GomegaStringer)   = (*HistogramBucketBoundsMatcher)(nil)
+)
+
+func (m *HistogramBucketBoundsMatcher) GomegaString() string {
+	bounds := make([]string, 0, len(m.bounds))
+	for _, bound := range m.bounds {
+		bounds = append(bounds, FormatFloat(bound))
+	}
+	return fmt.Sprintf("bucket bounds: [%s]", strings.Join(bounds, ", "))
+}
+
+func (m *HistogramBucketBoundsMatcher) yesimametricpropertymatcher() {}
+
+// matchMetric succeeds if the histogram of the passed metric has exactly the
+// expected bucket upper bounds, in the same order. A “+Inf” bucket is ignored
+// both in the expected as well as the actual bounds.
+func (m *HistogramBucketBoundsMatcher) matchMetric(mf *prommodel.MetricFamily, metric *prommodel.Metric) (bool, error) {
+	if !isHistogramType(mf.GetType()) {
+		return false, nil
+	}
+	actualBounds := []float64{}
+	for _, bucket := range metric.GetHistogram().GetBucket() {
+		actualBounds = append(actualBounds, bucket.GetUpperBound())
+	}
+	return slices.Equal(withoutInf(actualBounds), withoutInf(m.bounds)), nil
+}
+
+func (m *HistogramBucketBoundsMatcher) mismatchReason(mf *prommodel.MetricFamily, metric *prommodel.Metric) string {
+	return histogramMismatchReason(mf, metric)
+}
+
+// histogramMismatchReason returns the actual bucket layout of the passed
+// histogram metric as the reason for a bucket mismatch.
+func histogramMismatchReason(mf *prommodel.MetricFamily, metric *prommodel.Metric) string {
+	if !isHistogramType(mf.GetType()) {
+		return "no buckets for " + mf.GetType().String()
+	}
+	return "got " + histogramLayout(metric)
+}
+
+// withoutInf returns the passed bounds with a trailing “+Inf” bound removed.
+func withoutInf(bounds []float64) []float64 {
+	if len(bounds) != 0 && math.IsInf(bounds[len(bounds)-1], +1) {
+		return bounds[:len(bounds)-1]
+	}
+	return bounds
+}
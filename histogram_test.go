@@ -0,0 +1,185 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"math"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+	"github.com/prometheus/client_golang/prometheus"
+	prommodel "github.com/prometheus/client_model/go"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func puint64(u uint64) *uint64 {
+	return &u
+}
+
+var _ = Describe("histogram property matchers", func() {
+
+	histogramFamily := &prommodel.MetricFamily{
+		Name: pstr("foo_seconds"),
+		Type: prommodel.MetricType_HISTOGRAM.Enum(),
+		Metric: []*prommodel.Metric{
+			{
+				Label: []*prommodel.LabelPair{{Name: pstr("code"), Value: pstr("200")}},
+				Histogram: &prommodel.Histogram{
+					SampleCount: puint64(4),
+					SampleSum:   pfloat(1.25),
+					Bucket: []*prommodel.Bucket{
+						{UpperBound: pfloat(0.1), CumulativeCount: puint64(1)},
+						{UpperBound: pfloat(0.5), CumulativeCount: puint64(3)},
+						{UpperBound: pfloat(1), CumulativeCount: puint64(3)},
+					},
+				},
+			},
+			{
+				Label: []*prommodel.LabelPair{{Name: pstr("code"), Value: pstr("404")}},
+				Histogram: &prommodel.Histogram{
+					SampleCountFloat: pfloat(2),
+					SampleSum:        pfloat(0.2),
+					Bucket: []*prommodel.Bucket{
+						{UpperBound: pfloat(0.1), CumulativeCountFloat: pfloat(2)},
+						{UpperBound: pfloat(math.Inf(+1)), CumulativeCountFloat: pfloat(2)},
+					},
+				},
+			},
+		},
+	}
+
+	counterFamily := &prommodel.MetricFamily{
+		Name: pstr("foo_total"),
+		Type: prommodel.MetricType_COUNTER.Enum(),
+		Metric: []*prommodel.Metric{
+			{Counter: &prommodel.Counter{Value: pfloat(42)}},
+		},
+	}
+
+	DescribeTable("GomegaString",
+		func(m MetricPropertyMatcher, expected string) {
+			gs, ok := m.(format.GomegaStringer)
+			Expect(ok).To(BeTrue(), "not a GomegaStringer: %T", m)
+			Expect(gs.GomegaString()).To(MatchRegexp(expected))
+		},
+		Entry("sample count", HaveSampleCount(42), `^sample count: 42$`),
+		Entry("sample sum", HaveSampleSum(1.5), `^sample sum: 1.5$`),
+		Entry("bucket", HaveBucket(0.5, 3), `^bucket \{le=0.5\}: 3$`),
+		Entry("+Inf bucket", HaveBucket(math.Inf(+1), 3), `^bucket \{le=\+Inf\}: 3$`),
+		Entry("bucket bounds", HaveBucketBounds([]float64{0.1, 1}), `^bucket bounds: \[0.1, 1\]$`),
+	)
+
+	DescribeTable("rejecting invalid expected values",
+		func(m MetricPropertyMatcher) {
+			Expect(m.(individualMetricMatcher).matchMetric(histogramFamily, histogramFamily.Metric[0])).
+				Error().To(MatchError(ContainSubstring("to be either a number or GomegaMatcher")))
+		},
+		Entry("sample count", HaveSampleCount("42")),
+		Entry("sample sum", HaveSampleSum("42")),
+		Entry("bucket", HaveBucket(0.5, "42")),
+	)
+
+	DescribeTable("matching histogram properties",
+		func(metric *prommodel.Metric, m MetricPropertyMatcher, matchExpectations types.GomegaMatcher) {
+			Expect(m.(individualMetricMatcher).matchMetric(histogramFamily, metric)).To(matchExpectations)
+		},
+		Entry("sample count", histogramFamily.Metric[0], HaveSampleCount(4), BeTrue()),
+		Entry("float sample count", histogramFamily.Metric[1], HaveSampleCount(2), BeTrue()),
+		Entry("wrong sample count", histogramFamily.Metric[0], HaveSampleCount(BeZero()), BeFalse()),
+		Entry("sample sum", histogramFamily.Metric[0], HaveSampleSum(1.25), BeTrue()),
+		Entry("wrong sample sum", histogramFamily.Metric[0], HaveSampleSum(1.0), BeFalse()),
+		Entry("bucket", histogramFamily.Metric[0], HaveBucket(0.5, 3), BeTrue()),
+		Entry("float bucket", histogramFamily.Metric[1], HaveBucket(0.1, 2), BeTrue()),
+		Entry("bucket count matcher", histogramFamily.Metric[0], HaveBucket(1, BeNumerically(">=", 3)), BeTrue()),
+		Entry("wrong bucket count", histogramFamily.Metric[0], HaveBucket(0.5, 2), BeFalse()),
+		Entry("missing bucket", histogramFamily.Metric[0], HaveBucket(0.25, 3), BeFalse()),
+		Entry("bucket bounds", histogramFamily.Metric[0], HaveBucketBounds([]float64{0.1, 0.5, 1}), BeTrue()),
+		Entry("bucket bounds with +Inf", histogramFamily.Metric[0], HaveBucketBounds([]float64{0.1, 0.5, 1, math.Inf(+1)}), BeTrue()),
+		Entry("actual bucket bounds with +Inf", histogramFamily.Metric[1], HaveBucketBounds([]float64{0.1}), BeTrue()),
+		Entry("wrong bucket bounds", histogramFamily.Metric[0], HaveBucketBounds([]float64{0.1, 1}), BeFalse()),
+	)
+
+	DescribeTable("not matching other metric types",
+		func(m MetricPropertyMatcher) {
+			Expect(m.(individualMetricMatcher).matchMetric(counterFamily, counterFamily.Metric[0])).To(BeFalse())
+		},
+		Entry("sample count", HaveSampleCount(0)),
+		Entry("sample sum", HaveSampleSum(0)),
+		Entry("bucket", HaveBucket(0.5, 0)),
+		Entry("bucket bounds", HaveBucketBounds(nil)),
+	)
+
+	It("matches on the same metric as the labels", func() {
+		Expect(Histogram(HaveLabel("code=200"), HaveBucket(0.5, 3)).match(histogramFamily)).To(BeTrue())
+		Expect(Histogram(HaveLabel("code=404"), HaveBucket(0.5, 3)).match(histogramFamily)).To(BeFalse())
+		Expect(Histogram(HaveBucket(0.5, BeTrue())).match(histogramFamily)).Error().To(HaveOccurred())
+	})
+
+	It("reports the actual bucket layout", func() {
+		m := BeAMetric(Histogram(HaveName("foo_seconds"), HaveBucket(0.5, 42)))
+		Expect(m.Match(histogramFamily)).To(BeFalse())
+		Expect(m.FailureMessage(histogramFamily)).To(MatchRegexp(
+			`checklist:
+.*✓ type: HISTOGRAM
+.*✓ name: foo_seconds
+.*metric \{code="200"\}:
+.*✗ bucket \{le=0.5\}: 42, got \[le=0.1: 1, le=0.5: 3, le=1: 3\] count: 4, sum: 1.25
+.*metric \{code="404"\}:
+.*✗ bucket \{le=0.5\}: 42, got \[le=0.1: 2, le=\+Inf: 2\] count: 2, sum: 0.2`))
+
+		m = BeAMetric(Histogram(HaveBucketBounds([]float64{1, 2, 3})))
+		Expect(m.Match(histogramFamily)).To(BeFalse())
+		Expect(m.FailureMessage(histogramFamily)).To(ContainSubstring(
+			`✗ bucket bounds: [1, 2, 3], got [le=0.1: 1, le=0.5: 3, le=1: 3] count: 4, sum: 1.25`))
+	})
+
+	It("doesn't report stale bucket layouts", func() {
+		m := HaveBucket(0.5, 42)
+		Expect(Histogram(m).match(histogramFamily)).To(BeFalse())
+		Expect(gomegaString(m)).To(Equal("bucket {le=0.5}: 42"))
+		m = HaveBucketBounds([]float64{1})
+		Expect(Histogram(m).match(histogramFamily)).To(BeFalse())
+		Expect(gomegaString(m)).To(Equal("bucket bounds: [1]"))
+		Expect(m.(mismatchReasoner).mismatchReason(
+			&prommodel.MetricFamily{Type: prommodel.MetricType_SUMMARY.Enum()}, nil)).To(
+			Equal("no buckets for SUMMARY"))
+	})
+
+	It("reasons about collected histograms", func() {
+		h := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "foo_duration_seconds",
+			Help:    "foo duration.",
+			Buckets: []float64{0.1, 0.5, 1},
+		}, []string{"code"})
+		h.WithLabelValues("200").Observe(0.05)
+		h.WithLabelValues("200").Observe(0.3)
+		h.WithLabelValues("404").Observe(2)
+		Expect(CollectAndLint(h)).To(ContainMetrics(
+			Histogram(HaveName("foo_duration_seconds"),
+				HaveLabel("code=200"),
+				HaveSampleCount(2),
+				HaveSampleSum(BeNumerically("~", 0.35)),
+				HaveBucketBounds([]float64{0.1, 0.5, 1}),
+				HaveBucket(0.1, 1),
+				HaveBucket(0.5, 2)),
+			Histogram(HaveName("foo_duration_seconds"),
+				HaveLabel("code=404"),
+				HaveBucket(1, 0)),
+		))
+	})
+
+})
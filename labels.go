@@ -16,12 +16,15 @@ package pyrotest
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
 	prommodel "github.com/prometheus/client_model/go"
 
 	"github.com/onsi/gomega/format"
 	"github.com/onsi/gomega/types"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
 // HaveLabelMatcher succeeds if it matches an actual metric label by name and
@@ -39,6 +42,7 @@ type HaveLabelMatcher struct {
 var (
 	_ MetricPropertyMatcher = (*HaveLabelMatcher)(nil)
 	_ metricLabelMatcher    = (*HaveLabelMatcher)(nil)
+	_ mismatchReasoner      = (*HaveLabelMatcher)(nil)
 	_ format.GomegaStringer = (*HaveLabelMatcher)(nil)
 )
 
@@ -69,7 +73,8 @@ func (m *HaveLabelMatcher) yesimametricpropertymatcher() {}
 
 // GomegaString returns an optimized string representation for failure
 // reporting, reducing visual clutter as much as possible. In case both the
-// expected name and the value are plain string values. Otherwise, it falls back
+// expected name and the value are plain string values (or there's only a plain
+// string name without any value). Otherwise, it falls back
 // to reporting both name and value using Gomega's [format.Object]. In any case,
 // it never reports useless private state, such as the name of the matcher
 // constructor used, or the derived name and value matcher objects.
@@ -79,6 +84,9 @@ func (m *HaveLabelMatcher) GomegaString() string {
 	if nameOk && valueOk {
 		return fmt.Sprintf("label {%s=%s}", nameStr, valueStr)
 	}
+	if nameOk && m.value == nil {
+		return fmt.Sprintf("label {%s}", nameStr)
+	}
 	return fmt.Sprintf("label\n%sname: %s\n%svalue: %s",
 		format.Indent, format.Object(m.name, 1),
 		format.Indent, format.Object(m.value, 1))
@@ -112,6 +120,24 @@ func (m *HaveLabelMatcher) matchLabel(label *prommodel.LabelPair) (bool, error)
 	return success, nil
 }
 
+// mismatchReason returns the actual labels of the passed metric with matching
+// names, if any.
+func (m *HaveLabelMatcher) mismatchReason(_ *prommodel.MetricFamily, metric *prommodel.Metric) string {
+	var named []*prommodel.LabelPair
+	for _, label := range metric.GetLabel() {
+		if m.nameMatcher == nil {
+			break
+		}
+		if success, err := m.nameMatcher.Match(label.GetName()); err == nil && success {
+			named = append(named, label)
+		}
+	}
+	if len(named) == 0 {
+		return "no such label"
+	}
+	return "got " + labelsString(named)
+}
+
 // matchAllLabels succeeds if the all expected labels match (a subset of) the
 // actual labels. It returns an error as soon as any underlying label matcher
 // returns an error.
@@ -131,3 +157,179 @@ nextMatcher:
 	}
 	return true, nil
 }
+
+// matchAllAbsentLabels succeeds if none of the absent labels match any of the
+// actual labels. It returns an error as soon as any underlying label matcher
+// returns an error.
+func matchAllAbsentLabels(actual []*prommodel.LabelPair, absent []metricAbsentLabelMatcher) (bool, error) {
+	for _, matcher := range absent {
+		success, err := matcher.matchAbsentLabel(actual)
+		if err != nil {
+			return false, err
+		}
+		if !success {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// ----
+
+// NotHaveLabelMatcher succeeds if none of the actual labels of a metric match
+// by name and optionally by value.
+type NotHaveLabelMatcher struct {
+	label *HaveLabelMatcher
+}
+
+var (
+	_ MetricPropertyMatcher    = (*NotHaveLabelMatcher)(nil)
+	_ metricAbsentLabelMatcher = (*NotHaveLabelMatcher)(nil)
+	_ mismatchReasoner         = (*NotHaveLabelMatcher)(nil)
+	_ format.GomegaStringer    = (*NotHaveLabelMatcher)(nil)
+)
+
+func (m *NotHaveLabelMatcher) yesimametricpropertymatcher() {}
+
+func (m *NotHaveLabelMatcher) GomegaString() string {
+	return "no " + m.label.GomegaString()
+}
+
+// matchAbsentLabel returns true if none of the passed labels match the
+// configured name and optionally the value. Otherwise, it returns itself any
+// errors returned by the underlying label matcher.
+func (m *NotHaveLabelMatcher) matchAbsentLabel(labels []*prommodel.LabelPair) (bool, error) {
+	for _, lblPair := range labels {
+		success, err := m.label.matchLabel(lblPair)
+		if err != nil {
+			return false, err
+		}
+		if success {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// mismatchReason returns the actual labels of the passed metric that should
+// have been absent.
+func (m *NotHaveLabelMatcher) mismatchReason(_ *prommodel.MetricFamily, metric *prommodel.Metric) string {
+	var present []*prommodel.LabelPair
+	for _, label := range metric.GetLabel() {
+		if success, err := m.label.matchLabel(label); err == nil && success {
+			present = append(present, label)
+		}
+	}
+	return "got " + labelsString(present)
+}
+
+// ----
+
+// HaveExactLabelsMatcher succeeds if it matches all labels of an individual
+// metric, with no unexpected labels and no missing labels.
+type HaveExactLabelsMatcher struct {
+	labels []*HaveLabelMatcher
+}
+
+var (
+	_ MetricPropertyMatcher   = (*HaveExactLabelsMatcher)(nil)
+	_ individualMetricMatcher = (*HaveExactLabelsMatcher)(nil)
+	_ mismatchReasoner        = (*HaveExactLabelsMatcher)(nil)
+	_ format.GomegaStringer   = (*HaveExactLabelsMatcher)(nil)
+)
+
+// newHaveExactLabelsMatcher returns a new HaveExactLabelsMatcher for the
+// passed labels, where each label is specified in the same way as for
+// [HaveLabel].
+func newHaveExactLabelsMatcher(labels ...any) *HaveExactLabelsMatcher {
+	m := &HaveExactLabelsMatcher{}
+	for _, label := range labels {
+		m.labels = append(m.labels,
+			newHaveLabelMatcher(label, nil, "HaveExactLabels").(*HaveLabelMatcher))
+	}
+	return m
+}
+
+// newHaveOnlyLabelsMatcher returns a new HaveExactLabelsMatcher for the passed
+// label names and values.
+func newHaveOnlyLabelsMatcher(labels prometheus.Labels) *HaveExactLabelsMatcher {
+	m := &HaveExactLabelsMatcher{}
+	for _, name := range slices.Sorted(maps.Keys(labels)) {
+		m.labels = append(m.labels,
+			newHaveLabelMatcher(name, labels[name], "HaveOnlyLabels").(*HaveLabelMatcher))
+	}
+	return m
+}
+
+func (m *HaveExactLabelsMatcher) yesimametricpropertymatcher() {}
+
+// GomegaString returns the expected labels.
+func (m *HaveExactLabelsMatcher) GomegaString() string {
+	var s strings.Builder
+	s.WriteString("exact labels")
+	for _, label := range m.labels {
+		s.WriteRune('\n')
+		s.WriteString(format.IndentString(label.GomegaString(), 1))
+	}
+	return s.String()
+}
+
+// matchMetric succeeds if each expected label matches its own actual label of
+// the passed metric and there are no actual labels left unmatched.
+func (m *HaveExactLabelsMatcher) matchMetric(_ *prommodel.MetricFamily, metric *prommodel.Metric) (bool, error) {
+	missing, unexpected, err := m.assignLabels(metric.GetLabel())
+	if err != nil {
+		return false, err
+	}
+	return len(missing) == 0 && len(unexpected) == 0, nil
+}
+
+// mismatchReason returns the unexpected actual labels as well as the missing
+// expected labels of the passed metric.
+func (m *HaveExactLabelsMatcher) mismatchReason(_ *prommodel.MetricFamily, metric *prommodel.Metric) string {
+	missing, unexpected, _ := m.assignLabels(metric.GetLabel())
+	var reasons []string
+	if len(unexpected) != 0 {
+		reasons = append(reasons, "unexpected: "+labelsString(unexpected))
+	}
+	for _, label := range missing {
+		reasons = append(reasons, "missing: "+strings.TrimPrefix(label.GomegaString(), "label "))
+	}
+	return strings.Join(reasons, ", ")
+}
+
+// assignLabels assigns the expected labels one-to-one to the passed actual
+// labels, returning the expected labels without an assigned actual label, as
+// well as the actual labels without an assigned expected label. As an expected
+// label matcher might match multiple actual labels, such as a GomegaMatcher
+// for label names, this uses the same maximum bipartite matching as
+// [ConsistOfMetrics].
+func (m *HaveExactLabelsMatcher) assignLabels(actual []*prommodel.LabelPair) (missing []*HaveLabelMatcher, unexpected []*prommodel.LabelPair, err error) {
+	candidates := make([][]int, len(m.labels))
+	for matcherIdx, labelMatcher := range m.labels {
+		for labelIdx, lblPair := range actual {
+			success, err := labelMatcher.matchLabel(lblPair)
+			if err != nil {
+				return nil, nil, err
+			}
+			if success {
+				candidates[matcherIdx] = append(candidates[matcherIdx], labelIdx)
+			}
+		}
+	}
+	assignedMatchers := maximumBipartiteMatching(candidates, len(actual))
+	assigned := make([]bool, len(m.labels))
+	for labelIdx, matcherIdx := range assignedMatchers {
+		if matcherIdx < 0 {
+			unexpected = append(unexpected, actual[labelIdx])
+			continue
+		}
+		assigned[matcherIdx] = true
+	}
+	for matcherIdx, labelMatcher := range m.labels {
+		if !assigned[matcherIdx] {
+			missing = append(missing, labelMatcher)
+		}
+	}
+	return missing, unexpected, nil
+}
@@ -15,6 +15,8 @@
 package pyrotest
 
 import (
+	"github.com/onsi/gomega/types"
+	"github.com/prometheus/client_golang/prometheus"
 	prommodel "github.com/prometheus/client_model/go"
 
 	. "github.com/onsi/ginkgo/v2"
@@ -88,4 +90,94 @@ var _ = Describe("matching labels", func() {
 		Expect(matchLabels(labels, HaveLabel("foo=bar"), HaveLabel("baz=booze"))).To(BeFalse())
 	})
 
+	It("has a concise string representation for plain label names", func() {
+		Expect(HaveLabel("foo").(*HaveLabelMatcher).GomegaString()).To(Equal("label {foo}"))
+	})
+
+	Context("exact labels", func() {
+
+		exactFamily := &prommodel.MetricFamily{
+			Name: pstr("foo_total"),
+			Type: prommodel.MetricType_COUNTER.Enum(),
+			Metric: []*prommodel.Metric{
+				{Label: []*prommodel.LabelPair{
+					{Name: pstr("code"), Value: pstr("200")},
+					{Name: pstr("method"), Value: pstr("GET")},
+				}},
+				{Label: []*prommodel.LabelPair{
+					{Name: pstr("code"), Value: pstr("404")},
+					{Name: pstr("method"), Value: pstr("GET")},
+					{Name: pstr("user_id"), Value: pstr("1234")},
+				}},
+			},
+		}
+
+		DescribeTable("matching exact labels",
+			func(metric *prommodel.Metric, m MetricPropertyMatcher, matchExpectations types.GomegaMatcher) {
+				Expect(m.(individualMetricMatcher).matchMetric(exactFamily, metric)).To(matchExpectations)
+			},
+			Entry("exact names", exactFamily.Metric[0], HaveExactLabels("code", "method"), BeTrue()),
+			Entry("exact names and values", exactFamily.Metric[0], HaveExactLabels("method=GET", "code=200"), BeTrue()),
+			Entry("exact name matchers", exactFamily.Metric[0], HaveExactLabels(HavePrefix("co"), "method"), BeTrue()),
+			Entry("missing label", exactFamily.Metric[0], HaveExactLabels("code", "method", "path"), BeFalse()),
+			Entry("unexpected label", exactFamily.Metric[1], HaveExactLabels("code", "method"), BeFalse()),
+			Entry("wrong value", exactFamily.Metric[0], HaveExactLabels("code=404", "method"), BeFalse()),
+			Entry("only labels", exactFamily.Metric[0], HaveOnlyLabels(prometheus.Labels{"code": "200", "method": "GET"}), BeTrue()),
+			Entry("not only labels", exactFamily.Metric[1], HaveOnlyLabels(prometheus.Labels{"code": "404", "method": "GET"}), BeFalse()),
+			Entry("duplicate expected label", exactFamily.Metric[0], HaveExactLabels("code", "code"), BeFalse()),
+			Entry("duplicate expected label with other", exactFamily.Metric[0], HaveExactLabels("code", "code", "method"), BeFalse()),
+			Entry("matcher matching two labels", exactFamily.Metric[0], HaveExactLabels(MatchRegexp("^(code|method)$")), BeFalse()),
+			Entry("matcher and name sharing labels", exactFamily.Metric[0], HaveExactLabels(MatchRegexp("^(code|method)$"), "code"), BeTrue()),
+			Entry("two matchers sharing labels", exactFamily.Metric[0], HaveExactLabels(MatchRegexp("^(code|method)$"), MatchRegexp("^(code|method)$")), BeTrue()),
+		)
+
+		It("reports one-to-one label mismatches", func() {
+			m := BeAMetric(Counter(HaveExactLabels("code", "code", "method")))
+			Expect(m.Match(exactFamily)).To(BeFalse())
+			Expect(m.FailureMessage(exactFamily)).To(ContainSubstring(
+				`✗ exact labels, missing: {code}`))
+
+			m = BeAMetric(Counter(HaveExactLabels(MatchRegexp("^(code|method)$"))))
+			Expect(m.Match(exactFamily)).To(BeFalse())
+			Expect(m.FailureMessage(exactFamily)).To(ContainSubstring(
+				`✗ exact labels, unexpected: {method="GET"}`))
+		})
+
+		It("reports label matcher errors", func() {
+			Expect(HaveExactLabels(42).(individualMetricMatcher).matchMetric(exactFamily, exactFamily.Metric[0])).
+				Error().To(HaveOccurred())
+		})
+
+		It("matches on the same metric as other labels", func() {
+			Expect(Counter(HaveLabel("code=404"), HaveExactLabels("code", "method")).match(exactFamily)).To(BeFalse())
+			Expect(Counter(HaveLabel("code=404"), HaveExactLabels("code", "method", "user_id")).match(exactFamily)).To(BeTrue())
+			Expect(Counter(HaveExactLabels("code", "method")).match(exactFamily)).To(BeTrue())
+		})
+
+		It("reports unexpected and missing labels", func() {
+			exact := HaveExactLabels("code", "path")
+			m := BeAMetric(Counter(exact))
+			Expect(m.Match(exactFamily)).To(BeFalse())
+			Expect(m.FailureMessage(exactFamily)).To(MatchRegexp(
+				`COUNTER:
+.*exact labels
+.*label \{code\}
+.*label \{path\}
+(.|\n)*checklist:
+.*✓ type: COUNTER
+.*metric \{code="200",method="GET"\}:
+.*✗ exact labels, unexpected: \{method="GET"\}, missing: \{path\}
+.*metric \{code="404",method="GET",user_id="1234"\}:
+.*✗ exact labels, unexpected: \{method="GET",user_id="1234"\}, missing: \{path\}`))
+			Expect(gomegaString(exact)).NotTo(ContainSubstring("unexpected"))
+
+			m = BeAMetric(Counter(HaveOnlyLabels(prometheus.Labels{"code": "200"})))
+			Expect(m.Match(exactFamily)).To(BeFalse())
+			Expect(m.FailureMessage(exactFamily)).To(MatchRegexp(
+				`metric \{code="404",method="GET",user_id="1234"\}:
+.*✗ exact labels, unexpected: \{code="404",method="GET",user_id="1234"\}, missing: \{code=200\}`))
+		})
+
+	})
+
 })
@@ -0,0 +1,185 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus/testutil/promlint"
+	"github.com/prometheus/client_golang/prometheus/testutil/promlint/validations"
+	prommodel "github.com/prometheus/client_model/go"
+)
+
+// LintRule identifies one of the standard promlint validations, so that it can
+// be disabled using [DisableLintRules].
+type LintRule string
+
+// The standard promlint validations.
+const (
+	LintHelp                     LintRule = "help"
+	LintMetricUnits              LintRule = "metric-units"
+	LintCounter                  LintRule = "counter"
+	LintHistogramSummaryReserved LintRule = "histogram-summary-reserved"
+	LintMetricTypeInName         LintRule = "metric-type-in-name"
+	LintReservedChars            LintRule = "reserved-chars"
+	LintCamelCase                LintRule = "camel-case"
+	LintUnitAbbreviations        LintRule = "unit-abbreviations"
+	LintDuplicateMetric          LintRule = "duplicate-metric"
+)
+
+// lintRules lists the standard promlint validations in the same order as
+// promlint applies them.
+var lintRules = []struct {
+	rule       LintRule
+	validation promlint.Validation
+}{
+	{LintHelp, validations.LintHelp},
+	{LintMetricUnits, validations.LintMetricUnits},
+	{LintCounter, validations.LintCounter},
+	{LintHistogramSummaryReserved, validations.LintHistogramSummaryReserved},
+	{LintMetricTypeInName, validations.LintMetricTypeInName},
+	{LintReservedChars, validations.LintReservedChars},
+	{LintCamelCase, validations.LintCamelCase},
+	{LintUnitAbbreviations, validations.LintUnitAbbreviations},
+	{LintDuplicateMetric, validations.LintDuplicateMetric},
+}
+
+// LintOption configures the linting of metric families; pass lint options to
+// [LintWith] or [Tester.LintWith].
+type LintOption func(*lintOptions)
+
+// lintOptions configures which lint rules to apply and which linting problems
+// to suppress. A nil *lintOptions applies all standard lint rules without
+// suppressing any problems.
+type lintOptions struct {
+	disabled    []LintRule
+	suppressed  []string
+	suppressRes []*regexp.Regexp
+	validations []promlint.Validation
+	budgets     []CardinalityBudget // with total timeseries limits.
+}
+
+// DisableLintRules disables the specified standard promlint rules.
+func DisableLintRules(rules ...LintRule) LintOption {
+	return func(o *lintOptions) {
+		o.disabled = append(o.disabled, rules...)
+	}
+}
+
+// SuppressLintProblems suppresses all linting problems of the metric families
+// with the specified names.
+func SuppressLintProblems(metricNames ...string) LintOption {
+	return func(o *lintOptions) {
+		o.suppressed = append(o.suppressed, metricNames...)
+	}
+}
+
+// SuppressLintProblemsMatching suppresses all linting problems of the metric
+// families with names fully matching the specified regular expression.
+// SuppressLintProblemsMatching panics if the regular expression is invalid.
+func SuppressLintProblemsMatching(expr string) LintOption {
+	re := regexp.MustCompile("^(?:" + expr + ")$")
+	return func(o *lintOptions) {
+		o.suppressRes = append(o.suppressRes, re)
+	}
+}
+
+// AddLintValidations adds custom validations to the standard promlint rules.
+func AddLintValidations(vs ...promlint.Validation) LintOption {
+	return func(o *lintOptions) {
+		o.validations = append(o.validations, vs...)
+	}
+}
+
+// newLintOptions returns the lint options configured by the passed options on
+// top of the existing lint options, if any.
+func newLintOptions(existing *lintOptions, opts ...LintOption) *lintOptions {
+	lo := &lintOptions{}
+	if existing != nil {
+		lo.disabled = slices.Clone(existing.disabled)
+		lo.suppressed = slices.Clone(existing.suppressed)
+		lo.suppressRes = slices.Clone(existing.suppressRes)
+		lo.validations = slices.Clone(existing.validations)
+		lo.budgets = slices.Clone(existing.budgets)
+	}
+	for _, opt := range opts {
+		opt(lo)
+	}
+	return lo
+}
+
+// suppresses returns true if the linting problems of the metric family with the
+// specified name are to be suppressed.
+func (o *lintOptions) suppresses(metricName string) bool {
+	if o == nil {
+		return false
+	}
+	if slices.Contains(o.suppressed, metricName) {
+		return true
+	}
+	return slices.ContainsFunc(o.suppressRes, func(re *regexp.Regexp) bool {
+		return re.MatchString(metricName)
+	})
+}
+
+// problems returns the linting problems of the passed metric families, sorted
+// by metric name and problem description, in the same way as promlint does.
+// Problems concerning all metric families, such as exceeding a total
+// cardinality budget, have an empty metric name; metric families with
+// suppressed problems don't count towards the total cardinality budget.
+func (o *lintOptions) problems(metfams []*prommodel.MetricFamily) []promlint.Problem {
+	var enabled []promlint.Validation
+	for _, rule := range lintRules {
+		if o != nil && slices.Contains(o.disabled, rule.rule) {
+			continue
+		}
+		enabled = append(enabled, rule.validation)
+	}
+	if o != nil {
+		enabled = append(enabled, o.validations...)
+	}
+	var problems []promlint.Problem
+	var checked []*prommodel.MetricFamily
+	for _, metfam := range metfams {
+		if o.suppresses(metfam.GetName()) {
+			continue
+		}
+		checked = append(checked, metfam)
+		for _, validation := range enabled {
+			for _, err := range validation(metfam) {
+				problems = append(problems, promlint.Problem{
+					Metric: metfam.GetName(),
+					Text:   err.Error(),
+				})
+			}
+		}
+	}
+	if o != nil {
+		for _, budget := range o.budgets {
+			if err := budget.validateTotal(checked); err != nil {
+				problems = append(problems, promlint.Problem{Text: err.Error()})
+			}
+		}
+	}
+	slices.SortStableFunc(problems, func(a, b promlint.Problem) int {
+		if c := strings.Compare(a.Metric, b.Metric); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Text, b.Text)
+	})
+	return problems
+}
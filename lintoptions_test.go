@@ -0,0 +1,86 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil/promlint"
+	prommodel "github.com/prometheus/client_model/go"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("lint options", func() {
+
+	legacy := func(name string) *prommodel.MetricFamily {
+		return &prommodel.MetricFamily{
+			Name: pstr(name),
+			Help: pstr("legacy counter."),
+			Type: prommodel.MetricType_COUNTER.Enum(),
+		}
+	}
+
+	It("applies all standard rules by default", func() {
+		Expect(lintRules).To(HaveLen(9))
+		var lo *lintOptions
+		Expect(lo.problems([]*prommodel.MetricFamily{legacy("foo")})).To(ConsistOf(
+			HaveField("Text", `counter metrics should have "_total" suffix`)))
+	})
+
+	It("disables rules", func() {
+		lo := newLintOptions(nil, DisableLintRules(LintCounter))
+		Expect(lo.problems([]*prommodel.MetricFamily{legacy("foo")})).To(BeEmpty())
+	})
+
+	It("suppresses problems by metric name", func() {
+		lo := newLintOptions(nil,
+			SuppressLintProblems("foo"),
+			SuppressLintProblemsMatching("legacy_.*"))
+		Expect(lo.problems([]*prommodel.MetricFamily{
+			legacy("foo"),
+			legacy("legacy_bar"),
+			legacy("notlegacy_bar"),
+			legacy("legacy_baz"),
+		})).To(ConsistOf(
+			And(HaveField("Metric", "notlegacy_bar"), HaveField("Text", ContainSubstring("_total")))))
+		Expect(func() { SuppressLintProblemsMatching("(") }).To(Panic())
+	})
+
+	It("adds custom validations and sorts problems", func() {
+		lo := newLintOptions(nil, AddLintValidations(func(mf *prommodel.MetricFamily) []error {
+			return []error{errors.New("a custom problem")}
+		}))
+		lo = newLintOptions(lo, DisableLintRules(LintHelp))
+		Expect(lo.problems([]*prommodel.MetricFamily{legacy("foo_total"), legacy("bar")})).To(HaveExactElements(
+			promlint.Problem{Metric: "bar", Text: "a custom problem"},
+			promlint.Problem{Metric: "bar", Text: `counter metrics should have "_total" suffix`},
+			promlint.Problem{Metric: "foo_total", Text: "a custom problem"},
+		))
+	})
+
+	It("lints using testers", func() {
+		Expect(LintWith(DisableLintRules(LintCounter)).CollectAndLint(&fishyCollector{})).To(HaveLen(1))
+
+		reg := prometheus.NewPedanticRegistry()
+		Expect(reg.Register(&fishyCollector{})).To(Succeed())
+		Expect(LintWith(SuppressLintProblems("foo")).GatherAndLint(reg)).To(HaveLen(1))
+		Expect(Gather(reg)()).Error().To(HaveOccurred())
+		Expect(LintWith(SuppressLintProblems("foo")).Gather(reg)()).To(HaveLen(1))
+	})
+
+})
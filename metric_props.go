@@ -0,0 +1,176 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+	prommodel "github.com/prometheus/client_model/go"
+)
+
+// MetricValueMatcher matches the sample value of an individual counter, gauge,
+// or untyped metric.
+type MetricValueMatcher struct {
+	matcher  types.GomegaMatcher
+	expected any // original expected value for error reporting.
+}
+
+var (
+	_ (MetricPropertyMatcher)   = (*MetricValueMatcher)(nil)
+	_ (individualMetricMatcher) = (*MetricValueMatcher)(nil)
+	_ (mismatchReasoner)        = (*MetricValueMatcher)(nil)
+	_ (format.GomegaStringer)   = (*MetricValueMatcher)(nil)
+)
+
+func (m *MetricValueMatcher) GomegaString() string {
+	return fmt.Sprintf("value: %s", numberString(m.expected))
+}
+
+func (m *MetricValueMatcher) yesimametricpropertymatcher() {}
+
+// matchMetric matches the sample value of the passed metric, taking the type
+// of its metric family into account. Metrics of types without a single sample
+// value, such as histograms and summaries, never match.
+func (m *MetricValueMatcher) matchMetric(mf *prommodel.MetricFamily, metric *prommodel.Metric) (bool, error) {
+	if m.matcher == nil {
+		return false, errors.New(format.Message(
+			m.expected, "to be either a number or GomegaMatcher"))
+	}
+	var value float64
+	switch mf.GetType() {
+	case prommodel.MetricType_COUNTER:
+		value = metric.GetCounter().GetValue()
+	case prommodel.MetricType_GAUGE:
+		value = metric.GetGauge().GetValue()
+	case prommodel.MetricType_UNTYPED:
+		value = metric.GetUntyped().GetValue()
+	default:
+		return false, nil
+	}
+	return m.matcher.Match(value)
+}
+
+func (m *MetricValueMatcher) mismatchReason(mf *prommodel.MetricFamily, metric *prommodel.Metric) string {
+	switch mf.GetType() {
+	case prommodel.MetricType_COUNTER:
+		return "got " + FormatFloat(metric.GetCounter().GetValue())
+	case prommodel.MetricType_GAUGE:
+		return "got " + FormatFloat(metric.GetGauge().GetValue())
+	case prommodel.MetricType_UNTYPED:
+		return "got " + FormatFloat(metric.GetUntyped().GetValue())
+	}
+	return "no sample value for " + mf.GetType().String()
+}
+
+// ----
+
+// MetricSampleCountMatcher matches the sample count of an individual histogram
+// or summary metric.
+type MetricSampleCountMatcher struct {
+	matcher  types.GomegaMatcher
+	expected any // original expected value for error reporting.
+}
+
+var (
+	_ (MetricPropertyMatcher)   = (*MetricSampleCountMatcher)(nil)
+	_ (individualMetricMatcher) = (*MetricSampleCountMatcher)(nil)
+	_ (mismatchReasoner)        = (*MetricSampleCountMatcher)(nil)
+	_ (format.GomegaStringer)   = (*MetricSampleCountMatcher)(nil)
+)
+
+func (m *MetricSampleCountMatcher) GomegaString() string {
+	return fmt.Sprintf("sample count: %s", numberString(m.expected))
+}
+
+func (m *MetricSampleCountMatcher) yesimametricpropertymatcher() {}
+
+// matchMetric matches the sample count of the passed metric, taking the type
+// of its metric family into account. Metrics of types without a sample count,
+// such as counters and gauges, never match.
+func (m *MetricSampleCountMatcher) matchMetric(mf *prommodel.MetricFamily, metric *prommodel.Metric) (bool, error) {
+	if m.matcher == nil {
+		return false, errors.New(format.Message(
+			m.expected, "to be either a number or GomegaMatcher"))
+	}
+	switch {
+	case isHistogramType(mf.GetType()):
+		return m.matcher.Match(histogramSampleCount(metric.GetHistogram()))
+	case mf.GetType() == prommodel.MetricType_SUMMARY:
+		return m.matcher.Match(float64(metric.GetSummary().GetSampleCount()))
+	}
+	return false, nil
+}
+
+func (m *MetricSampleCountMatcher) mismatchReason(mf *prommodel.MetricFamily, metric *prommodel.Metric) string {
+	switch {
+	case isHistogramType(mf.GetType()):
+		return "got " + FormatFloat(histogramSampleCount(metric.GetHistogram()))
+	case mf.GetType() == prommodel.MetricType_SUMMARY:
+		return "got " + FormatFloat(float64(metric.GetSummary().GetSampleCount()))
+	}
+	return "no sample count for " + mf.GetType().String()
+}
+
+// ----
+
+// MetricSampleSumMatcher matches the sample sum of an individual histogram or
+// summary metric.
+type MetricSampleSumMatcher struct {
+	matcher  types.GomegaMatcher
+	expected any // original expected value for error reporting.
+}
+
+var (
+	_ (MetricPropertyMatcher)   = (*MetricSampleSumMatcher)(nil)
+	_ (individualMetricMatcher) = (*MetricSampleSumMatcher)(nil)
+	_ (mismatchReasoner)        = (*MetricSampleSumMatcher)(nil)
+	_ (format.GomegaStringer)   = (*MetricSampleSumMatcher)(nil)
+)
+
+func (m *MetricSampleSumMatcher) GomegaString() string {
+	return fmt.Sprintf("sample sum: %s", numberString(m.expected))
+}
+
+func (m *MetricSampleSumMatcher) yesimametricpropertymatcher() {}
+
+// matchMetric matches the sample sum of the passed metric, taking the type of
+// its metric family into account. Metrics of types without a sample sum, such
+// as counters and gauges, never match.
+func (m *MetricSampleSumMatcher) matchMetric(mf *prommodel.MetricFamily, metric *prommodel.Metric) (bool, error) {
+	if m.matcher == nil {
+		return false, errors.New(format.Message(
+			m.expected, "to be either a number or GomegaMatcher"))
+	}
+	switch {
+	case isHistogramType(mf.GetType()):
+		return m.matcher.Match(metric.GetHistogram().GetSampleSum())
+	case mf.GetType() == prommodel.MetricType_SUMMARY:
+		return m.matcher.Match(metric.GetSummary().GetSampleSum())
+	}
+	return false, nil
+}
+
+func (m *MetricSampleSumMatcher) mismatchReason(mf *prommodel.MetricFamily, metric *prommodel.Metric) string {
+	switch {
+	case isHistogramType(mf.GetType()):
+		return "got " + FormatFloat(metric.GetHistogram().GetSampleSum())
+	case mf.GetType() == prommodel.MetricType_SUMMARY:
+		return "got " + FormatFloat(metric.GetSummary().GetSampleSum())
+	}
+	return "no sample sum for " + mf.GetType().String()
+}
@@ -0,0 +1,118 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+	prommodel "github.com/prometheus/client_model/go"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func pfloat(f float64) *float64 {
+	return &f
+}
+
+var _ = Describe("individual metric property matchers", func() {
+
+	counterFamily := &prommodel.MetricFamily{
+		Name: pstr("foo_total"),
+		Type: prommodel.MetricType_COUNTER.Enum(),
+		Metric: []*prommodel.Metric{
+			{
+				Label:   []*prommodel.LabelPair{{Name: pstr("code"), Value: pstr("200")}},
+				Counter: &prommodel.Counter{Value: pfloat(42)},
+			},
+			{
+				Label:   []*prommodel.LabelPair{{Name: pstr("code"), Value: pstr("404")}},
+				Counter: &prommodel.Counter{Value: pfloat(6)},
+			},
+		},
+	}
+
+	gaugeFamily := &prommodel.MetricFamily{
+		Name: pstr("foo_bar"),
+		Type: prommodel.MetricType_GAUGE.Enum(),
+		Metric: []*prommodel.Metric{
+			{Gauge: &prommodel.Gauge{Value: pfloat(66.6)}},
+		},
+	}
+
+	untypedFamily := &prommodel.MetricFamily{
+		Name: pstr("foo_baz"),
+		Type: prommodel.MetricType_UNTYPED.Enum(),
+		Metric: []*prommodel.Metric{
+			{Untyped: &prommodel.Untyped{Value: pfloat(-1)}},
+		},
+	}
+
+	histogramFamily := &prommodel.MetricFamily{
+		Name: pstr("foo_seconds"),
+		Type: prommodel.MetricType_HISTOGRAM.Enum(),
+		Metric: []*prommodel.Metric{
+			{Histogram: &prommodel.Histogram{SampleSum: pfloat(42)}},
+		},
+	}
+
+	DescribeTable("GomegaString",
+		func(m MetricPropertyMatcher, expected string) {
+			gs, ok := m.(format.GomegaStringer)
+			Expect(ok).To(BeTrue(), "not a GomegaStringer: %T", m)
+			Expect(gs.GomegaString()).To(MatchRegexp(expected))
+		},
+		Entry("value", HaveSampleValue(42.0), `^value: 42$`),
+		Entry("value/matcher", HaveSampleValue(BeNumerically(">", 0)),
+			`value: .*BeNumericallyMatcher.*Comparator: ">"`),
+	)
+
+	It("rejects an invalid expected value", func() {
+		Expect(HaveSampleValue("42").(individualMetricMatcher).
+			matchMetric(gaugeFamily, gaugeFamily.Metric[0])).Error().To(MatchError(
+			ContainSubstring("to be either a number or GomegaMatcher")))
+	})
+
+	DescribeTable("matching sample values",
+		func(mf *prommodel.MetricFamily, m MetricPropertyMatcher, matchExpectations types.GomegaMatcher) {
+			Expect(m.(individualMetricMatcher).matchMetric(mf, mf.Metric[0])).To(matchExpectations)
+		},
+		Entry("counter", counterFamily, HaveSampleValue(42.0), BeTrue()),
+		Entry("counter with integer", counterFamily, HaveSampleValue(42), BeTrue()),
+		Entry("wrong counter", counterFamily, HaveSampleValue(41.0), BeFalse()),
+		Entry("gauge", gaugeFamily, HaveSampleValue(BeNumerically("~", 66.6)), BeTrue()),
+		Entry("untyped", untypedFamily, HaveSampleValue(BeNumerically("<", 0)), BeTrue()),
+		Entry("histogram", histogramFamily, HaveSampleValue(42.0), BeFalse()),
+	)
+
+	It("matches the sample value on the same metric as the labels", func() {
+		Expect(Counter(HaveLabel("code=200"), HaveSampleValue(42)).match(counterFamily)).To(BeTrue())
+		Expect(Counter(HaveLabel("code=404"), HaveSampleValue(6)).match(counterFamily)).To(BeTrue())
+		Expect(Counter(HaveLabel("code=404"), HaveSampleValue(42)).match(counterFamily)).To(BeFalse())
+		Expect(Counter(HaveSampleValue(6)).match(counterFamily)).To(BeTrue())
+		Expect(Counter(HaveSampleValue(BeTrue())).match(counterFamily)).Error().To(HaveOccurred())
+	})
+
+	It("includes individual metric properties in failure messages", func() {
+		m := BeAMetric(Counter(HaveName("foo_total"), HaveLabel("code=200"), HaveSampleValue(666)))
+		Expect(m.Match(counterFamily)).To(BeFalse())
+		Expect(m.FailureMessage(counterFamily)).To(MatchRegexp(
+			`COUNTER:
+.*name: foo_total
+.*label \{code=200\}
+.*value: 666`))
+	})
+
+})
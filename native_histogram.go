@@ -0,0 +1,346 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+	prommodel "github.com/prometheus/client_model/go"
+)
+
+// HistogramKind differentiates between classic histograms with fixed buckets,
+// native (sparse) histograms with exponential buckets, and hybrid histograms
+// that carry both classic and native buckets.
+type HistogramKind int
+
+const (
+	ClassicHistogram HistogramKind = iota // histogram with classic buckets only.
+	NativeHistogram                       // histogram with native buckets only.
+	HybridHistogram                       // histogram with both classic and native buckets.
+)
+
+// String returns the name of the histogram kind.
+func (k HistogramKind) String() string {
+	switch k {
+	case ClassicHistogram:
+		return "classic"
+	case NativeHistogram:
+		return "native"
+	case HybridHistogram:
+		return "hybrid"
+	}
+	return fmt.Sprintf("HistogramKind(%d)", int(k))
+}
+
+// isNativeHistogram returns true if the passed histogram carries native
+// histogram information. Following Prometheus' own logic, a histogram is native
+// if it has a zero threshold, zero count, or any spans. Please note that
+// client_golang adds a no-op span to native histograms without any
+// observations and a zero threshold of zero for exactly this reason.
+func isNativeHistogram(h *prommodel.Histogram) bool {
+	return h.GetZeroThreshold() != 0 ||
+		h.GetZeroCount() != 0 || h.GetZeroCountFloat() != 0 ||
+		len(h.GetPositiveSpan()) != 0 || len(h.GetNegativeSpan()) != 0
+}
+
+// histogramKind returns the kind of the passed histogram.
+func histogramKind(h *prommodel.Histogram) HistogramKind {
+	if !isNativeHistogram(h) {
+		return ClassicHistogram
+	}
+	if len(h.GetBucket()) != 0 {
+		return HybridHistogram
+	}
+	return NativeHistogram
+}
+
+// nativeBucket is a decoded native histogram bucket with its lower and upper
+// bounds and its (non-cumulative) count.
+type nativeBucket struct {
+	lower, upper float64
+	count        float64
+}
+
+// String returns the bucket in interval notation, together with its count.
+func (b nativeBucket) String() string {
+	return fmt.Sprintf("%s: %s", intervalString(b.lower, b.upper), FormatFloat(b.count))
+}
+
+// intervalString returns the bounds of a native bucket in the usual interval
+// notation, where positive buckets are open to the left and negative buckets
+// are open to the right.
+func intervalString(lower, upper float64) string {
+	if upper <= 0 {
+		return fmt.Sprintf("[%s,%s)", FormatFloat(lower), FormatFloat(upper))
+	}
+	return fmt.Sprintf("(%s,%s]", FormatFloat(lower), FormatFloat(upper))
+}
+
+// nativeBuckets returns the decoded negative and positive buckets of a native
+// histogram, not including the zero bucket. The negative buckets come first,
+// ordered from the smallest (most negative) to largest bounds, followed by the
+// positive buckets in ascending order.
+func nativeBuckets(h *prommodel.Histogram) []nativeBucket {
+	negatives := decodeNativeBuckets(h.GetSchema(),
+		h.GetNegativeSpan(), h.GetNegativeDelta(), h.GetNegativeCount())
+	positives := decodeNativeBuckets(h.GetSchema(),
+		h.GetPositiveSpan(), h.GetPositiveDelta(), h.GetPositiveCount())
+	buckets := make([]nativeBucket, 0, len(negatives)+len(positives))
+	for idx := len(negatives) - 1; idx >= 0; idx-- {
+		b := negatives[idx]
+		buckets = append(buckets, nativeBucket{lower: -b.upper, upper: -b.lower, count: b.count})
+	}
+	return append(buckets, positives...)
+}
+
+// decodeNativeBuckets decodes the spans and either the delta-encoded integer
+// counts or the absolute float counts of one side of a native histogram into
+// buckets with (absolute) bounds. The bucket with index i has the bounds
+// (base^(i-1), base^i], where base = 2^(2^-schema).
+func decodeNativeBuckets(schema int32, spans []*prommodel.BucketSpan, deltas []int64, counts []float64) []nativeBucket {
+	var buckets []nativeBucket
+	var index int32
+	var count int64
+	pos := 0
+	for spanIdx, span := range spans {
+		if spanIdx == 0 {
+			index = span.GetOffset()
+		} else {
+			index += span.GetOffset()
+		}
+		for range span.GetLength() {
+			var c float64
+			switch {
+			case pos < len(deltas):
+				count += deltas[pos]
+				c = float64(count)
+			case pos < len(counts):
+				c = counts[pos]
+			default:
+				// broken histogram with fewer counts than its spans
+				// indicate; stop here.
+				return buckets
+			}
+			buckets = append(buckets, nativeBucket{
+				lower: nativeBucketBound(schema, index-1),
+				upper: nativeBucketBound(schema, index),
+				count: c,
+			})
+			index++
+			pos++
+		}
+	}
+	return buckets
+}
+
+// nativeBucketBound returns the upper bound of the positive native histogram
+// bucket with the specified index, given the schema.
+func nativeBucketBound(schema int32, index int32) float64 {
+	if schema <= 0 {
+		return math.Ldexp(1, int(index)<<-schema)
+	}
+	return math.Exp2(float64(index) / float64(int(1)<<schema))
+}
+
+// approxEqual returns true if a and b are equal within a tiny relative
+// tolerance, as native histogram bucket bounds are calculated and thus might
+// slightly differ from expected bounds computed elsewhere.
+func approxEqual(a, b float64) bool {
+	if a == b {
+		return true
+	}
+	return math.Abs(a-b) <= 1e-9*math.Max(math.Abs(a), math.Abs(b))
+}
+
+// nativeHistogramLayout returns a concise textual representation of the native
+// bucket layout of a histogram, including schema, zero threshold, and zero
+// count.
+func nativeHistogramLayout(h *prommodel.Histogram) string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "schema: %d, zero threshold: %s, zero count: %s, native buckets: [",
+		h.GetSchema(), FormatFloat(h.GetZeroThreshold()), FormatFloat(zeroCount(h)))
+	for idx, bucket := range nativeBuckets(h) {
+		if idx > 0 {
+			s.WriteString(", ")
+		}
+		s.WriteString(bucket.String())
+	}
+	s.WriteRune(']')
+	return s.String()
+}
+
+// zeroCount returns the zero bucket count of a native histogram as a float64,
+// regardless of it being an integer or float histogram.
+func zeroCount(h *prommodel.Histogram) float64 {
+	if h.ZeroCountFloat != nil {
+		return h.GetZeroCountFloat()
+	}
+	return float64(h.GetZeroCount())
+}
+
+// nativeHistogramOf returns the histogram of the passed metric if the metric
+// family is of a histogram type and the histogram is a native or hybrid one;
+// otherwise, it returns nil.
+func nativeHistogramOf(mf *prommodel.MetricFamily, metric *prommodel.Metric) *prommodel.Histogram {
+	if !isHistogramType(mf.GetType()) || !isNativeHistogram(metric.GetHistogram()) {
+		return nil
+	}
+	return metric.GetHistogram()
+}
+
+// ----
+
+// HistogramKindMatcher matches the kind of a histogram: classic, native, or
+// hybrid.
+type HistogramKindMatcher struct {
+	kind HistogramKind
+}
+
+var (
+	_ (MetricPropertyMatcher)   = (*HistogramKindMatcher)(nil)
+	_ (individualMetricMatcher) = (*HistogramKindMatcher)(nil)
+	_ (mismatchReasoner)        = (*HistogramKindMatcher)(nil)
+	_ (format.GomegaStringer)   = (*HistogramKindMatcher)(nil)
+)
+
+func (m *HistogramKindMatcher) GomegaString() string {
+	return fmt.Sprintf("histogram kind: %s", m.kind)
+}
+
+func (m *HistogramKindMatcher) yesimametricpropertymatcher() {}
+
+func (m *HistogramKindMatcher) matchMetric(mf *prommodel.MetricFamily, metric *prommodel.Metric) (bool, error) {
+	if !isHistogramType(mf.GetType()) {
+		return false, nil
+	}
+	return histogramKind(metric.GetHistogram()) == m.kind, nil
+}
+
+func (m *HistogramKindMatcher) mismatchReason(mf *prommodel.MetricFamily, metric *prommodel.Metric) string {
+	if !isHistogramType(mf.GetType()) {
+		return "no histogram kind for " + mf.GetType().String()
+	}
+	return fmt.Sprintf("got %s histogram", histogramKind(metric.GetHistogram()))
+}
+
+// ----
+
+// NativeHistogramPropertyMatcher matches a property of a native histogram,
+// such as its schema, zero threshold, or zero count.
+type NativeHistogramPropertyMatcher struct {
+	property string // name of the property for failure reporting.
+	value    func(*prommodel.Histogram) float64
+	matcher  types.GomegaMatcher
+	expected any // original expected value for error reporting.
+}
+
+var (
+	_ (MetricPropertyMatcher)   = (*NativeHistogramPropertyMatcher)(nil)
+	_ (individualMetricMatcher) = (*NativeHistogramPropertyMatcher)(nil)
+	_ (mismatchReasoner)        = (*NativeHistogramPropertyMatcher)(nil)
+	_ (format.GomegaStringer)   = (*NativeHistogramPropertyMatcher)(nil)
+)
+
+func (m *NativeHistogramPropertyMatcher) GomegaString() string {
+	return fmt.Sprintf("%s: %s", m.property, numberString(m.expected))
+}
+
+func (m *NativeHistogramPropertyMatcher) yesimametricpropertymatcher() {}
+
+// matchMetric matches the property of a native (or hybrid) histogram; classic
+// histograms and other metric types never match.
+func (m *NativeHistogramPropertyMatcher) matchMetric(mf *prommodel.MetricFamily, metric *prommodel.Metric) (bool, error) {
+	if m.matcher == nil {
+		return false, errors.New(format.Message(
+			m.expected, "to be either a number or GomegaMatcher"))
+	}
+	h := nativeHistogramOf(mf, metric)
+	if h == nil {
+		return false, nil
+	}
+	return m.matcher.Match(m.value(h))
+}
+
+func (m *NativeHistogramPropertyMatcher) mismatchReason(mf *prommodel.MetricFamily, metric *prommodel.Metric) string {
+	h := nativeHistogramOf(mf, metric)
+	if h == nil {
+		return "not a native histogram"
+	}
+	return fmt.Sprintf("got %s %s", m.property, FormatFloat(m.value(h)))
+}
+
+// ----
+
+// NativeHistogramBucketMatcher matches a decoded native histogram bucket with
+// specific lower and upper bounds and its count.
+type NativeHistogramBucketMatcher struct {
+	lower, upper float64
+	matcher      types.GomegaMatcher
+	expected     any // original expected count for error reporting.
+}
+
+var (
+	_ (MetricPropertyMatcher)   = (*NativeHistogramBucketMatcher)(nil)
+	_ (individualMetricMatcher) = (*NativeHistogramBucketMatcher)(nil)
+	_ (mismatchReasoner)        = (*NativeHistogramBucketMatcher)(nil)
+	_ (format.GomegaStringer)   = (*NativeHistogramBucketMatcher)(nil)
+)
+
+func (m *NativeHistogramBucketMatcher) GomegaString() string {
+	return fmt.Sprintf("native bucket %s: %s",
+		intervalString(m.lower, m.upper), numberString(m.expected))
+}
+
+func (m *NativeHistogramBucketMatcher) yesimametricpropertymatcher() {}
+
+// matchMetric succeeds if the native histogram of the passed metric has a
+// bucket with the expected bounds and a matching count.
+func (m *NativeHistogramBucketMatcher) matchMetric(mf *prommodel.MetricFamily, metric *prommodel.Metric) (bool, error) {
+	if m.matcher == nil {
+		return false, errors.New(format.Message(
+			m.expected, "to be either a number or GomegaMatcher"))
+	}
+	h := nativeHistogramOf(mf, metric)
+	if h == nil {
+		return false, nil
+	}
+	for _, bucket := range nativeBuckets(h) {
+		if !approxEqual(bucket.lower, m.lower) || !approxEqual(bucket.upper, m.upper) {
+			continue
+		}
+		success, err := m.matcher.Match(bucket.count)
+		if err != nil {
+			return false, err
+		}
+		if success {
+			return true, nil
+		}
+		break
+	}
+	return false, nil
+}
+
+func (m *NativeHistogramBucketMatcher) mismatchReason(mf *prommodel.MetricFamily, metric *prommodel.Metric) string {
+	h := nativeHistogramOf(mf, metric)
+	if h == nil {
+		return "not a native histogram"
+	}
+	return "got " + nativeHistogramLayout(h)
+}
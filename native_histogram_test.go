@@ -0,0 +1,255 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"math"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+	"github.com/prometheus/client_golang/prometheus"
+	prommodel "github.com/prometheus/client_model/go"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+func pint32(i int32) *int32 {
+	return &i
+}
+
+func puint32(u uint32) *uint32 {
+	return &u
+}
+
+var _ = Describe("native histogram property matchers", func() {
+
+	nativeHistogram := &prommodel.Histogram{
+		SampleCount:   puint64(13),
+		SampleSum:     pfloat(42),
+		Schema:        pint32(0),
+		ZeroThreshold: pfloat(0.001),
+		ZeroCount:     puint64(1),
+		PositiveSpan: []*prommodel.BucketSpan{
+			{Offset: pint32(0), Length: puint32(2)},
+			{Offset: pint32(1), Length: puint32(1)},
+		},
+		PositiveDelta: []int64{2, -1, 3},
+		NegativeSpan: []*prommodel.BucketSpan{
+			{Offset: pint32(1), Length: puint32(1)},
+		},
+		NegativeDelta: []int64{5},
+	}
+
+	floatHistogram := &prommodel.Histogram{
+		SampleCountFloat: pfloat(3.5),
+		Schema:           pint32(1),
+		ZeroCountFloat:   pfloat(0.5),
+		PositiveSpan: []*prommodel.BucketSpan{
+			{Offset: pint32(-1), Length: puint32(2)},
+		},
+		PositiveCount: []float64{1.5, 1.5},
+	}
+
+	classicHistogram := &prommodel.Histogram{
+		SampleCount: puint64(1),
+		Bucket: []*prommodel.Bucket{
+			{UpperBound: pfloat(1), CumulativeCount: puint64(1)},
+		},
+	}
+
+	hybridHistogram := &prommodel.Histogram{
+		SampleCount: puint64(1),
+		Bucket: []*prommodel.Bucket{
+			{UpperBound: pfloat(1), CumulativeCount: puint64(1)},
+		},
+		Schema: pint32(3),
+		PositiveSpan: []*prommodel.BucketSpan{
+			{Offset: pint32(0), Length: puint32(0)},
+		},
+	}
+
+	histogramFamily := &prommodel.MetricFamily{
+		Name: pstr("foo_seconds"),
+		Type: prommodel.MetricType_HISTOGRAM.Enum(),
+		Metric: []*prommodel.Metric{
+			{
+				Label:     []*prommodel.LabelPair{{Name: pstr("kind"), Value: pstr("native")}},
+				Histogram: nativeHistogram,
+			},
+			{
+				Label:     []*prommodel.LabelPair{{Name: pstr("kind"), Value: pstr("float")}},
+				Histogram: floatHistogram,
+			},
+			{
+				Label:     []*prommodel.LabelPair{{Name: pstr("kind"), Value: pstr("classic")}},
+				Histogram: classicHistogram,
+			},
+			{
+				Label:     []*prommodel.LabelPair{{Name: pstr("kind"), Value: pstr("hybrid")}},
+				Histogram: hybridHistogram,
+			},
+		},
+	}
+
+	It("decodes native buckets", func() {
+		Expect(nativeBuckets(nativeHistogram)).To(Equal([]nativeBucket{
+			{lower: -2, upper: -1, count: 5},
+			{lower: 0.5, upper: 1, count: 2},
+			{lower: 1, upper: 2, count: 1},
+			{lower: 4, upper: 8, count: 4},
+		}))
+		Expect(nativeBuckets(floatHistogram)).To(Equal([]nativeBucket{
+			{lower: 0.5, upper: math.Exp2(-0.5), count: 1.5},
+			{lower: math.Exp2(-0.5), upper: 1, count: 1.5},
+		}))
+		Expect(nativeBuckets(&prommodel.Histogram{
+			PositiveSpan:  []*prommodel.BucketSpan{{Offset: pint32(0), Length: puint32(2)}},
+			PositiveDelta: []int64{1},
+		})).To(HaveLen(1))
+	})
+
+	It("calculates native bucket bounds", func() {
+		Expect(nativeBucketBound(0, 0)).To(Equal(1.0))
+		Expect(nativeBucketBound(0, 3)).To(Equal(8.0))
+		Expect(nativeBucketBound(-1, 1)).To(Equal(4.0))
+		Expect(nativeBucketBound(-2, -1)).To(Equal(1.0 / 16))
+		Expect(nativeBucketBound(3, 8)).To(Equal(2.0))
+		Expect(nativeBucketBound(3, 1)).To(BeNumerically("~", 1.0905, 0.0001))
+	})
+
+	DescribeTable("determining the histogram kind",
+		func(h *prommodel.Histogram, kind HistogramKind) {
+			Expect(histogramKind(h)).To(Equal(kind))
+		},
+		Entry(nil, classicHistogram, ClassicHistogram),
+		Entry(nil, nativeHistogram, NativeHistogram),
+		Entry(nil, floatHistogram, NativeHistogram),
+		Entry(nil, hybridHistogram, HybridHistogram),
+	)
+
+	It("stringifies histogram kinds", func() {
+		Expect(ClassicHistogram.String()).To(Equal("classic"))
+		Expect(NativeHistogram.String()).To(Equal("native"))
+		Expect(HybridHistogram.String()).To(Equal("hybrid"))
+		Expect(HistogramKind(42).String()).To(Equal("HistogramKind(42)"))
+	})
+
+	DescribeTable("GomegaString",
+		func(m MetricPropertyMatcher, expected string) {
+			gs, ok := m.(format.GomegaStringer)
+			Expect(ok).To(BeTrue(), "not a GomegaStringer: %T", m)
+			Expect(gs.GomegaString()).To(MatchRegexp(expected))
+		},
+		Entry("kind", HaveHistogramKind(NativeHistogram), `^histogram kind: native$`),
+		Entry("schema", HaveSchema(3), `^schema: 3$`),
+		Entry("zero threshold", HaveZeroThreshold(0.001), `^zero threshold: 0.001$`),
+		Entry("zero count", HaveZeroCount(BeZero()), `^zero count: .*BeZeroMatcher`),
+		Entry("positive bucket", HaveNativeBucket(1, 2, 42), `^native bucket \(1,2\]: 42$`),
+		Entry("negative bucket", HaveNativeBucket(-2, -1, 42), `^native bucket \[-2,-1\): 42$`),
+	)
+
+	DescribeTable("rejecting invalid expected values",
+		func(m MetricPropertyMatcher) {
+			Expect(m.(individualMetricMatcher).matchMetric(histogramFamily, histogramFamily.Metric[0])).
+				Error().To(MatchError(ContainSubstring("to be either a number or GomegaMatcher")))
+		},
+		Entry("schema", HaveSchema("42")),
+		Entry("native bucket", HaveNativeBucket(1, 2, "42")),
+	)
+
+	DescribeTable("matching native histogram properties",
+		func(metric *prommodel.Metric, m MetricPropertyMatcher, matchExpectations types.GomegaMatcher) {
+			Expect(m.(individualMetricMatcher).matchMetric(histogramFamily, metric)).To(matchExpectations)
+		},
+		Entry("native kind", histogramFamily.Metric[0], HaveHistogramKind(NativeHistogram), BeTrue()),
+		Entry("not classic kind", histogramFamily.Metric[0], HaveHistogramKind(ClassicHistogram), BeFalse()),
+		Entry("classic kind", histogramFamily.Metric[2], HaveHistogramKind(ClassicHistogram), BeTrue()),
+		Entry("hybrid kind", histogramFamily.Metric[3], HaveHistogramKind(HybridHistogram), BeTrue()),
+		Entry("schema", histogramFamily.Metric[0], HaveSchema(0), BeTrue()),
+		Entry("wrong schema", histogramFamily.Metric[0], HaveSchema(1), BeFalse()),
+		Entry("no classic schema", histogramFamily.Metric[2], HaveSchema(0), BeFalse()),
+		Entry("hybrid schema", histogramFamily.Metric[3], HaveSchema(3), BeTrue()),
+		Entry("zero threshold", histogramFamily.Metric[0], HaveZeroThreshold(0.001), BeTrue()),
+		Entry("zero count", histogramFamily.Metric[0], HaveZeroCount(1), BeTrue()),
+		Entry("float zero count", histogramFamily.Metric[1], HaveZeroCount(0.5), BeTrue()),
+		Entry("positive bucket", histogramFamily.Metric[0], HaveNativeBucket(4, 8, 4), BeTrue()),
+		Entry("negative bucket", histogramFamily.Metric[0], HaveNativeBucket(-2, -1, 5), BeTrue()),
+		Entry("wrong bucket count", histogramFamily.Metric[0], HaveNativeBucket(1, 2, 2), BeFalse()),
+		Entry("missing bucket", histogramFamily.Metric[0], HaveNativeBucket(2, 4, 0), BeFalse()),
+		Entry("float bucket", histogramFamily.Metric[1], HaveNativeBucket(math.Sqrt2/2, 1, 1.5), BeTrue()),
+		Entry("no classic bucket", histogramFamily.Metric[2], HaveNativeBucket(0.5, 1, 1), BeFalse()),
+	)
+
+	It("doesn't match other metric types", func() {
+		counterFamily := &prommodel.MetricFamily{
+			Name:   pstr("foo_total"),
+			Type:   prommodel.MetricType_COUNTER.Enum(),
+			Metric: []*prommodel.Metric{{Counter: &prommodel.Counter{Value: pfloat(0)}}},
+		}
+		Expect(HaveHistogramKind(ClassicHistogram).(individualMetricMatcher).
+			matchMetric(counterFamily, counterFamily.Metric[0])).To(BeFalse())
+		Expect(HaveSchema(0).(individualMetricMatcher).
+			matchMetric(counterFamily, counterFamily.Metric[0])).To(BeFalse())
+	})
+
+	It("reports the actual native bucket layout", func() {
+		m := BeAMetric(Histogram(HaveLabel("kind=native"), HaveNativeBucket(1, 2, 42)))
+		Expect(m.Match(histogramFamily)).To(BeFalse())
+		Expect(m.FailureMessage(histogramFamily)).To(MatchRegexp(
+			`metric \{kind="native"\}:
+.*✓ label \{kind=native\}
+.*✗ native bucket \(1,2\]: 42, got schema: 0, zero threshold: 0.001, zero count: 1, native buckets: \[\[-2,-1\): 5, \(0.5,1\]: 2, \(1,2\]: 1, \(4,8\]: 4\]`))
+		Expect(m.FailureMessage(histogramFamily)).To(MatchRegexp(
+			`metric \{kind="classic"\}:
+.*✗ label \{kind=native\}, got \{kind="classic"\}
+.*✗ native bucket \(1,2\]: 42, not a native histogram`))
+	})
+
+	It("doesn't report stale native buckets", func() {
+		m := HaveNativeBucket(1, 2, 42)
+		Expect(Histogram(m).match(histogramFamily)).To(BeFalse())
+		Expect(gomegaString(m)).To(Equal("native bucket (1,2]: 42"))
+	})
+
+	It("reasons about collected native histograms", func() {
+		h := prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:                        "foo_duration_seconds",
+			Help:                        "foo duration.",
+			NativeHistogramBucketFactor: 1.1,
+		})
+		h.Observe(1)
+		h.Observe(1)
+		h.Observe(0)
+		Expect(CollectAndLint(h)).To(ContainMetrics(
+			Histogram(HaveName("foo_duration_seconds"),
+				HaveHistogramKind(NativeHistogram),
+				HaveSchema(3),
+				HaveZeroCount(1),
+				HaveZeroThreshold(BeNumerically(">", 0)),
+				HaveNativeBucket(math.Exp2(-1.0/8), 1, 2)),
+		))
+
+		h = prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:                        "foo_duration_seconds",
+			Help:                        "foo duration.",
+			Buckets:                     []float64{1},
+			NativeHistogramBucketFactor: 1.1,
+		})
+		Expect(CollectAndLint(h)).To(ContainMetrics(
+			Histogram(HaveHistogramKind(HybridHistogram))))
+	})
+
+})
@@ -0,0 +1,227 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/onsi/gomega/format"
+	prommodel "github.com/prometheus/client_model/go"
+)
+
+// maxNearMisses limits the number of near misses reported per missing
+// expected metric.
+const maxNearMisses = 5
+
+// nearMisser returns the near misses of an expected metric in the passed metric
+// families for failure reporting, such as metric families with the same name
+// but a different type, or with similar names. Additionally, it also reports
+// individual metrics that match all but one of the expected labels and
+// properties.
+type nearMisser interface {
+	nearMissSubject() string
+	nearMisses(families MetricsFamilies) []string
+}
+
+var _ nearMisser = (*TypedMetricFamilyMatcher)(nil)
+
+// nearMissSubject returns a short description of the expected metric.
+func (m *TypedMetricFamilyMatcher) nearMissSubject() string {
+	if m.plainName != "" {
+		return fmt.Sprintf("%s %q", m.expectedType(), m.plainName)
+	}
+	return m.expectedType() + " metric"
+}
+
+// nearMisses returns textual descriptions of the near misses of this expected
+// metric in the passed metric families.
+func (m *TypedMetricFamilyMatcher) nearMisses(families MetricsFamilies) []string {
+	misses := []string{}
+	for _, name := range slices.Sorted(maps.Keys(families)) {
+		mf := families[name]
+		if mf == nil || !m.matchesName(mf) {
+			continue
+		}
+		if !m.anyType && mf.GetType() != m.typ {
+			// without any name constraint at all every other metric family
+			// would be a “near miss”, so don't report these.
+			if m.hasNameConstraint() {
+				misses = append(misses, fmt.Sprintf("metric family %q has type %s instead of %s",
+					name, mf.GetType(), m.typ))
+			}
+			continue
+		}
+		if props := m.mismatchingProperties(mf); len(props) != 0 {
+			misses = append(misses, fmt.Sprintf("metric family %q mismatches %s",
+				name, strings.Join(props, ", ")))
+			continue
+		}
+		misses = append(misses, m.nearMissMetrics(mf)...)
+	}
+	if m.plainName != "" {
+		for _, similar := range similarNames(m.plainName, families) {
+			misses = append(misses, fmt.Sprintf("metric family %q of type %s has a similar name",
+				similar, families[similar].GetType()))
+		}
+	}
+	if len(misses) > maxNearMisses {
+		misses = append(misses[:maxNearMisses],
+			fmt.Sprintf("...and %d more", len(misses)-maxNearMisses))
+	}
+	return misses
+}
+
+// hasNameConstraint returns true if this matcher expects a specific metric
+// family name, either a plain name or a name matching a GomegaMatcher.
+func (m *TypedMetricFamilyMatcher) hasNameConstraint() bool {
+	if m.plainName != "" {
+		return true
+	}
+	return slices.ContainsFunc(m.propertyMatchers, func(pm metricPropertyMatcher) bool {
+		_, ok := pm.(*MetricFamilyNameMatcher)
+		return ok
+	})
+}
+
+// matchesName returns true if the name of the passed metric family matches the
+// expected name, or if there is no expected name at all.
+func (m *TypedMetricFamilyMatcher) matchesName(mf *prommodel.MetricFamily) bool {
+	if m.plainName != "" {
+		return mf.GetName() == m.plainName
+	}
+	for _, pm := range m.propertyMatchers {
+		if nm, ok := pm.(*MetricFamilyNameMatcher); ok {
+			if success, err := nm.matchProperty(mf); err != nil || !success {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// mismatchingProperties returns the descriptions of the expected metric family
+// properties, other than the name, that the passed metric family doesn't
+// match.
+func (m *TypedMetricFamilyMatcher) mismatchingProperties(mf *prommodel.MetricFamily) []string {
+	var props []string
+	for _, pm := range m.propertyMatchers {
+		if _, ok := pm.(*MetricFamilyNameMatcher); ok {
+			continue
+		}
+		if success, err := pm.matchProperty(mf); err != nil || !success {
+			props = append(props, gomegaString(pm))
+		}
+	}
+	return props
+}
+
+// nearMissMetrics returns the descriptions of the individual metrics of the
+// passed metric family that match all but exactly one of the expected labels,
+// absent labels, and individual metric properties.
+func (m *TypedMetricFamilyMatcher) nearMissMetrics(mf *prommodel.MetricFamily) []string {
+	var misses []string
+	for _, metric := range mf.GetMetric() {
+		var failed []string
+		for _, lm := range m.labelMatchers {
+			if success, err := matchAllLabels(metric.GetLabel(), []metricLabelMatcher{lm}); err != nil || !success {
+				failed = append(failed, gomegaString(lm))
+			}
+		}
+		for _, am := range m.absentMatchers {
+			if success, err := am.matchAbsentLabel(metric.GetLabel()); err != nil || !success {
+				failed = append(failed, gomegaString(am))
+			}
+		}
+		for _, mm := range m.metricMatchers {
+			if success, err := mm.matchMetric(mf, metric); err != nil || !success {
+				failed = append(failed, firstLine(gomegaString(mm)))
+			}
+		}
+		if len(failed) != 1 {
+			continue
+		}
+		misses = append(misses, fmt.Sprintf("metric %s%s matches all but %s",
+			mf.GetName(), labelsString(metric.GetLabel()), failed[0]))
+	}
+	return misses
+}
+
+// gomegaString returns the GomegaString representation of the passed matcher.
+func gomegaString(m any) string {
+	if gs, ok := m.(format.GomegaStringer); ok {
+		return gs.GomegaString()
+	}
+	return fmt.Sprintf("%v", m)
+}
+
+// firstLine returns only the first line of the passed string.
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
+
+// similarNames returns the names of the passed metric families that are
+// similar, but not identical, to the passed name, sorted by decreasing
+// similarity. Names are similar if their edit distance is at most a quarter of
+// the length of the passed name, but at least 2.
+func similarNames(name string, families MetricsFamilies) []string {
+	type candidate struct {
+		name     string
+		distance int
+	}
+	maxDistance := max(2, len(name)/4)
+	var candidates []candidate
+	for other := range families {
+		if other == name {
+			continue
+		}
+		if d := editDistance(name, other); d <= maxDistance {
+			candidates = append(candidates, candidate{name: other, distance: d})
+		}
+	}
+	slices.SortFunc(candidates, func(a, b candidate) int {
+		return cmp.Or(cmp.Compare(a.distance, b.distance), strings.Compare(a.name, b.name))
+	})
+	names := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// editDistance returns the Levenshtein distance between the strings a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
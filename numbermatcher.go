@@ -0,0 +1,52 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"fmt"
+
+	"github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+)
+
+// asNumberMatcher expects a to be either a number (such as a float64 or an
+// integer) or a types.GomegaMatcher and then always returns a suitable
+// types.GomegaMatcher, otherwise nil in case of an unsupported value type of a.
+func asNumberMatcher(a any) types.GomegaMatcher {
+	switch v := a.(type) {
+	case float64, float32,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64:
+		return gomega.BeNumerically("==", v)
+	case types.GomegaMatcher:
+		return v
+	default:
+		return nil
+	}
+}
+
+// numberString returns a concise representation of an expected number or
+// matcher for use in GomegaString implementations.
+func numberString(a any) string {
+	switch a.(type) {
+	case float64, float32,
+		int, int8, int16, int32, int64,
+		uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%v", a)
+	default:
+		return format.Object(a, 1)
+	}
+}
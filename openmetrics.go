@@ -0,0 +1,266 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	prommodel "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// openMetricsTranslation is the result of translating the OpenMetrics text
+// format into the classic Prometheus text format, alongside with the metric
+// family details that the classic text format cannot express.
+type openMetricsTranslation struct {
+	text            string            // translated classic text format.
+	units           map[string]string // metric family units, indexed by (translated) family name.
+	gaugeHistograms map[string]bool   // (translated) family names of gauge histograms.
+}
+
+// openMetricsFamily describes an OpenMetrics metric family as declared by its
+// TYPE metadata.
+type openMetricsFamily struct {
+	name string // translated family name.
+	typ  string // OpenMetrics type name.
+}
+
+// parseOpenMetrics parses the OpenMetrics text format into metric families.
+// It translates the OpenMetrics text format into the classic text format, then
+// parses that using [expfmt.TextParser] and finally adds the units and gauge
+// histogram types that the classic text format cannot express.
+//
+// Any exemplars and “_created” samples are ignored. Line numbers in parse
+// errors refer to the original OpenMetrics text.
+func parseOpenMetrics(r io.Reader) (map[string]*prommodel.MetricFamily, error) {
+	translated, err := translateOpenMetrics(r)
+	if err != nil {
+		return nil, err
+	}
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(strings.NewReader(translated.text))
+	if err != nil {
+		return nil, err
+	}
+	for name, unit := range translated.units {
+		if family, ok := families[name]; ok {
+			family.Unit = &unit
+		}
+	}
+	for name := range translated.gaugeHistograms {
+		if family, ok := families[name]; ok {
+			family.Type = prommodel.MetricType_GAUGE_HISTOGRAM.Enum()
+		}
+	}
+	return families, nil
+}
+
+// translateOpenMetrics translates the OpenMetrics text format into the
+// classic text format, line by line. Lines without a classic counterpart are
+// replaced by empty lines, so that line numbers stay the same.
+func translateOpenMetrics(r io.Reader) (*openMetricsTranslation, error) {
+	translated := &openMetricsTranslation{
+		units:           map[string]string{},
+		gaugeHistograms: map[string]bool{},
+	}
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// As HELP and UNIT metadata might come before the TYPE metadata, we
+	// first need to learn about all declared metric families and their
+	// types.
+	families := map[string]openMetricsFamily{}
+	for _, line := range lines {
+		fields := strings.SplitN(line, " ", 4)
+		if len(fields) != 4 || fields[0] != "#" || fields[1] != "TYPE" {
+			continue
+		}
+		family := openMetricsFamily{name: fields[2], typ: fields[3]}
+		switch family.typ {
+		case "counter":
+			if !strings.HasSuffix(family.name, "_total") {
+				family.name += "_total"
+			}
+		case "gaugehistogram":
+			translated.gaugeHistograms[family.name] = true
+		case "info":
+			family.name += "_info"
+		}
+		families[fields[2]] = family
+	}
+	var text strings.Builder
+	eof := false
+	for idx, line := range lines {
+		if eof {
+			return nil, expfmt.ParseError{Line: idx + 1, Msg: "unexpected content after # EOF"}
+		}
+		translatedLine, err := translateOpenMetricsLine(line, families, translated)
+		if err != nil {
+			return nil, expfmt.ParseError{Line: idx + 1, Msg: err.Error()}
+		}
+		if line == "# EOF" {
+			eof = true
+		}
+		text.WriteString(translatedLine)
+		text.WriteRune('\n')
+	}
+	if !eof {
+		return nil, expfmt.ParseError{Line: max(len(lines), 1), Msg: "missing # EOF"}
+	}
+	translated.text = text.String()
+	return translated, nil
+}
+
+// translateOpenMetricsLine translates a single line of the OpenMetrics text
+// format, taking the declared families into account.
+func translateOpenMetricsLine(line string, families map[string]openMetricsFamily, translated *openMetricsTranslation) (string, error) {
+	if line == "" || line == "# EOF" {
+		return "", nil
+	}
+	if strings.HasPrefix(line, "#") {
+		fields := strings.SplitN(line, " ", 4)
+		if len(fields) < 3 {
+			return line, nil
+		}
+		name := fields[2]
+		rest := ""
+		if len(fields) == 4 {
+			rest = fields[3]
+		}
+		switch fields[1] {
+		case "TYPE":
+			typ := rest
+			switch rest {
+			case "gaugehistogram":
+				typ = "histogram"
+			case "unknown":
+				typ = "untyped"
+			case "info", "stateset":
+				typ = "gauge"
+			}
+			return "# TYPE " + families[name].name + " " + typ, nil
+		case "HELP":
+			if family, ok := families[name]; ok {
+				name = family.name
+			}
+			return "# HELP " + name + " " + translateOpenMetricsHelp(rest), nil
+		case "UNIT":
+			if family, ok := families[name]; ok {
+				name = family.name
+			}
+			translated.units[name] = rest
+			return "", nil
+		}
+		return line, nil
+	}
+	return translateOpenMetricsSample(line, families)
+}
+
+// translateOpenMetricsHelp translates an escaped OpenMetrics help text into
+// the classic text format, which doesn't know about escaped double quotes.
+// Escape sequences are processed left to right in a single pass, so that an
+// escaped backslash followed by a double quote stays exactly that.
+func translateOpenMetricsHelp(help string) string {
+	var s strings.Builder
+	for idx := 0; idx < len(help); idx++ {
+		if help[idx] != '\\' || idx+1 == len(help) {
+			s.WriteByte(help[idx])
+			continue
+		}
+		idx++
+		if help[idx] != '"' {
+			s.WriteByte('\\')
+		}
+		s.WriteByte(help[idx])
+	}
+	return s.String()
+}
+
+// translateOpenMetricsSample translates an OpenMetrics sample line into the
+// classic text format, dropping any exemplar and converting the optional
+// timestamp from seconds into milliseconds.
+func translateOpenMetricsSample(line string, families map[string]openMetricsFamily) (string, error) {
+	name, labels, rest, err := splitOpenMetricsSample(line)
+	if err != nil {
+		return "", err
+	}
+	if base, ok := strings.CutSuffix(name, "_created"); ok {
+		if family, ok := families[base]; ok && family.typ != "gauge" && family.typ != "unknown" {
+			return "", nil
+		}
+	}
+	if base, ok := strings.CutSuffix(name, "_gcount"); ok && families[base].typ == "gaugehistogram" {
+		name = base + "_count"
+	} else if base, ok := strings.CutSuffix(name, "_gsum"); ok && families[base].typ == "gaugehistogram" {
+		name = base + "_sum"
+	}
+	// drop any exemplar.
+	if idx := strings.Index(rest, "#"); idx >= 0 {
+		rest = rest[:idx]
+	}
+	fields := strings.Fields(rest)
+	switch len(fields) {
+	case 1:
+		return name + labels + " " + fields[0], nil
+	case 2:
+		ts, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid timestamp %q", fields[1])
+		}
+		return name + labels + " " + fields[0] + " " +
+			strconv.FormatInt(int64(math.Round(ts*1000)), 10), nil
+	}
+	return "", fmt.Errorf("invalid sample %q", line)
+}
+
+// splitOpenMetricsSample splits a sample line into its metric name, label set
+// (including the curly braces), and the remaining value, timestamp and
+// exemplar part.
+func splitOpenMetricsSample(line string) (name, labels, rest string, err error) {
+	end := strings.IndexAny(line, "{ ")
+	if end <= 0 {
+		return "", "", "", fmt.Errorf("invalid sample %q", line)
+	}
+	name = line[:end]
+	if line[end] != '{' {
+		return name, "", line[end:], nil
+	}
+	inQuotes := false
+	for idx := end + 1; idx < len(line); idx++ {
+		switch line[idx] {
+		case '\\':
+			idx++ // skip escaped character
+		case '"':
+			inQuotes = !inQuotes
+		case '}':
+			if !inQuotes {
+				return name, line[end : idx+1], line[idx+1:], nil
+			}
+		}
+	}
+	return "", "", "", errors.New("unterminated label set")
+}
@@ -0,0 +1,148 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"strings"
+	"time"
+
+	prommodel "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const openMetricsExposition = `# HELP foo Foo "counts".
+# TYPE foo counter
+# UNIT foo things
+foo_total{code="200"} 42 1712345678.5 # {trace_id="abc"} 1 1712345678.25
+foo_created{code="200"} 1712345000
+# TYPE bar_seconds histogram
+# HELP bar_seconds Bar durations.
+bar_seconds_bucket{le="0.1"} 1
+bar_seconds_bucket{le="+Inf"} 2
+bar_seconds_count 2
+bar_seconds_sum 0.35
+bar_seconds_created 1712345000
+# TYPE baz gaugehistogram
+baz_bucket{le="1"} 3
+baz_bucket{le="+Inf"} 4
+baz_gcount 4
+baz_gsum 2.5
+# TYPE build info
+build_info{version="1.2.3",note="a } in # braces"} 1
+# TYPE state stateset
+state{state="on"} 1
+# TYPE weird unknown
+weird 0
+# EOF
+`
+
+var _ = Describe("OpenMetrics text format", func() {
+
+	It("parses OpenMetrics text", func() {
+		families, err := parseOpenMetrics(strings.NewReader(openMetricsExposition))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(families).To(HaveLen(6))
+		Expect(families).To(ConsistOfMetrics(
+			Counter(HaveName("foo_total"),
+				HaveHelp(`Foo "counts".`),
+				HaveUnit("things"),
+				HaveLabel("code=200"),
+				HaveSampleValue(42),
+				HaveTimestamp(BeTemporally("~", time.UnixMilli(1712345678500)))),
+			Histogram(HaveName("bar_seconds"),
+				HaveHelp("Bar durations."),
+				HaveSampleCount(2),
+				HaveBucket(0.1, 1)),
+			GaugeHistogram(HaveName("baz"),
+				HaveSampleCount(4),
+				HaveSampleSum(2.5),
+				HaveBucket(1, 3)),
+			Gauge(HaveName("build_info"), HaveLabel(`note=a } in # braces`)),
+			Gauge(HaveName("state"), HaveLabel("state=on")),
+			Untyped(HaveName("weird")),
+		))
+	})
+
+	DescribeTable("reporting line-numbered parse errors",
+		func(exposition string, line int, msg string) {
+			_, err := parseOpenMetrics(strings.NewReader(exposition))
+			var perr expfmt.ParseError
+			Expect(err).To(BeAssignableToTypeOf(perr))
+			perr = err.(expfmt.ParseError)
+			Expect(perr.Line).To(Equal(line))
+			Expect(perr.Msg).To(ContainSubstring(msg))
+		},
+		Entry("missing EOF", "# TYPE foo gauge\nfoo 1\n", 2, "missing # EOF"),
+		Entry("content after EOF", "# TYPE foo gauge\nfoo 1\n# EOF\nfoo 2\n", 4, "unexpected content after # EOF"),
+		Entry("invalid timestamp", "# TYPE foo gauge\nfoo 1 abc\n# EOF\n", 2, `invalid timestamp "abc"`),
+		Entry("invalid sample", "# TYPE foo gauge\nfoo 1 2 3\n# EOF\n", 2, "invalid sample"),
+		Entry("missing value", "# TYPE foo gauge\n\nfoo\n# EOF\n", 3, "invalid sample"),
+		Entry("unterminated labels", "# TYPE foo gauge\nfoo{bar=\"}\" 1\n# EOF\n", 2, "unterminated label set"),
+		Entry("classic text error", "# TYPE foo gauge\n\n\nfoo{bar=1} 1\n# EOF\n", 4, "expected '\"'"),
+		Entry("empty exposition", "", 1, "missing # EOF"),
+		Entry("EOF with trailing space", "# TYPE foo gauge\nfoo 1\n# EOF \n", 3, "missing # EOF"),
+		Entry("EOF without space", "# TYPE foo gauge\nfoo 1\n#EOF\n", 3, "missing # EOF"),
+		Entry("empty line after EOF", "# TYPE foo gauge\nfoo 1\n# EOF\n\n", 4, "unexpected content after # EOF"),
+		Entry("comment after EOF", "# TYPE foo gauge\nfoo 1\n# EOF\n# EOF\n", 4, "unexpected content after # EOF"),
+	)
+
+	It("accepts a lone EOF", func() {
+		families, err := parseOpenMetrics(strings.NewReader("# EOF"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(families).To(BeEmpty())
+	})
+
+	DescribeTable("parsing label values with special characters",
+		func(labels string, value string) {
+			families, err := parseOpenMetrics(strings.NewReader(
+				"# TYPE foo gauge\nfoo{" + labels + "} 1 # {trace_id=\"#\"} 1\n# EOF\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(families).To(ConsistOfMetrics(
+				Gauge(HaveName("foo"), HaveLabel("bar="+value), HaveSampleValue(1))))
+		},
+		Entry("escaped quote", `bar="a \"quoted\" value"`, `a "quoted" value`),
+		Entry("escaped quote and closing brace", `bar="\"}"`, `"}`),
+		Entry("hash", `bar="#"`, "#"),
+		Entry("hash after escaped quote", `bar="\" # {x=\"y\"} 2"`, `" # {x="y"} 2`),
+		Entry("escaped backslash before closing quote", `bar="a\\",baz="#"`, `a\`),
+		Entry("escaped newline", `bar="a\nb"`, "a\nb"),
+		Entry("escaped backslash followed by escaped quote", `bar="a\\\"b"`, `a\"b`),
+	)
+
+	DescribeTable("parsing help texts with escapes",
+		func(help string, expected string) {
+			families, err := parseOpenMetrics(strings.NewReader(
+				"# TYPE foo gauge\n# HELP foo " + help + "\nfoo 1\n# EOF\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(families).To(ConsistOfMetrics(
+				Gauge(HaveName("foo"), HaveHelp(expected))))
+		},
+		Entry("escaped quote", `a \"quoted\" help`, `a "quoted" help`),
+		Entry("escaped backslash", `a\\b`, `a\b`),
+		Entry("escaped backslash followed by quote", `a\\"b`, `a\"b`),
+		Entry("escaped backslash followed by escaped quote", `a\\\"b`, `a\"b`),
+		Entry("escaped newline", `a\nb`, "a\nb"),
+	)
+
+	It("passes through other comments", func() {
+		families, err := parseOpenMetrics(strings.NewReader("# just a comment\n#\n# TYPE foo gauge\nfoo 1\n# EOF\n"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(families).To(ConsistOf(HaveField("GetType()", prommodel.MetricType_GAUGE)))
+	})
+
+})
@@ -0,0 +1,152 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+
+	prommodel "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+
+	gi "github.com/onsi/ginkgo/v2"
+	gom "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
+)
+
+// ParseText parses metrics in the Prometheus text format or OpenMetrics text
+// format from the passed reader, returning them as a [MetricsFamilies] map. If
+// the text cannot be parsed, ParseText will fail the current test with details
+// about the parse error, including the line number.
+//
+// ParseText detects the OpenMetrics text format by its mandatory “# EOF”
+// line. Please note that exemplars in the OpenMetrics text format are ignored.
+func ParseText(r io.Reader) MetricsFamilies {
+	gi.GinkgoHelper()
+	return parseText(gom.Default, r, "")
+}
+
+// ParseTextFile parses metrics in the Prometheus text format or OpenMetrics
+// text format from the file with the passed path, returning them as a
+// [MetricsFamilies] map. If the file cannot be read or parsed, ParseTextFile
+// will fail the current test with details about the problem, including the
+// line number.
+func ParseTextFile(path string) MetricsFamilies {
+	gi.GinkgoHelper()
+	return parseTextFile(gom.Default, path)
+}
+
+// ParseTextAndLint parses metrics in the Prometheus text format or OpenMetrics
+// text format from the passed reader, linting them, and finally returns them if
+// there are neither parse errors nor linting issues. Otherwise,
+// ParseTextAndLint will fail the current test with details about parsing or
+// linting problems.
+//
+// If any metric names are passed in, only metrics with those names are checked.
+func ParseTextAndLint(r io.Reader, metricNames ...string) MetricsFamilies {
+	gi.GinkgoHelper()
+	return parseTextAndLint(gom.Default, nil, r, "", metricNames...)
+}
+
+// ParseTextFileAndLint parses metrics in the Prometheus text format or
+// OpenMetrics text format from the file with the passed path, linting them, and
+// finally returns them if there are neither errors nor linting issues.
+// Otherwise, ParseTextFileAndLint will fail the current test with details about
+// reading, parsing or linting problems.
+//
+// If any metric names are passed in, only metrics with those names are checked.
+func ParseTextFileAndLint(path string, metricNames ...string) MetricsFamilies {
+	gi.GinkgoHelper()
+	return parseTextFileAndLint(gom.Default, nil, path, metricNames...)
+}
+
+func parseText(gomega types.Gomega, r io.Reader, name string) MetricsFamilies {
+	gi.GinkgoHelper()
+	THelper(gomega)()
+	metfams, err := decodeText(r, name)
+	gomega.Expect(err).NotTo(gom.HaveOccurred(), "parsing metrics text failed")
+	return maps.Collect(allFamilies(metfams))
+}
+
+func parseTextFile(gomega types.Gomega, path string) MetricsFamilies {
+	gi.GinkgoHelper()
+	THelper(gomega)()
+	f, err := os.Open(path)
+	gomega.Expect(err).NotTo(gom.HaveOccurred(), "opening metrics text file failed")
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+	return parseText(gomega, f, path)
+}
+
+func parseTextAndLint(gomega types.Gomega, lo *lintOptions, r io.Reader, name string, metricNames ...string) MetricsFamilies {
+	gi.GinkgoHelper()
+	THelper(gomega)()
+	metfams, err := decodeText(r, name)
+	gomega.Expect(err).NotTo(gom.HaveOccurred(), "parsing metrics text failed")
+	return lint(gomega, lo, metfams, metricNames...)
+}
+
+func parseTextFileAndLint(gomega types.Gomega, lo *lintOptions, path string, metricNames ...string) MetricsFamilies {
+	gi.GinkgoHelper()
+	THelper(gomega)()
+	f, err := os.Open(path)
+	gomega.Expect(err).NotTo(gom.HaveOccurred(), "opening metrics text file failed")
+	if err != nil {
+		return nil
+	}
+	defer func() { _ = f.Close() }()
+	return parseTextAndLint(gomega, lo, f, path, metricNames...)
+}
+
+// decodeText decodes the metric families in the Prometheus text format or
+// OpenMetrics text format from the passed reader, returning them sorted by
+// name. If a name (such as a file path) is passed, it prefixes any error.
+func decodeText(r io.Reader, name string) ([]*prommodel.MetricFamily, error) {
+	text, err := io.ReadAll(r)
+	if err == nil {
+		contentType := "text/plain"
+		if isOpenMetricsText(text) {
+			contentType = expfmt.OpenMetricsType
+		}
+		var metfams []*prommodel.MetricFamily
+		metfams, err = decodeExposition(text, contentType)
+		if err == nil {
+			return metfams, nil
+		}
+	}
+	if name != "" {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return nil, err
+}
+
+// isOpenMetricsText returns true if the passed text contains an OpenMetrics
+// “# EOF” line.
+func isOpenMetricsText(text []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(text))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if scanner.Text() == "# EOF" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+const textExposition = `# HELP foo_total foo.
+# TYPE foo_total counter
+foo_total{code="200"} 42
+# HELP bar bar.
+# TYPE bar gauge
+bar 1
+`
+
+var _ = Describe("parsing metrics text", func() {
+
+	It("parses the Prometheus text format", func() {
+		Expect(ParseText(strings.NewReader(textExposition))).To(ConsistOfMetrics(
+			Counter(HaveName("foo_total"), HaveLabel("code=200"), HaveSampleValue(42)),
+			Gauge(HaveName("bar"), HaveSampleValue(1))))
+	})
+
+	It("parses the OpenMetrics text format", func() {
+		Expect(ParseText(strings.NewReader(openMetricsExposition))).To(ContainMetrics(
+			Counter(HaveName("foo_total"), HaveUnit("things"), HaveSampleValue(42)),
+			GaugeHistogram(HaveName("baz"))))
+	})
+
+	It("parses and lints text files", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "metrics.prom")
+		Expect(os.WriteFile(path, []byte(textExposition), 0o644)).To(Succeed())
+		Expect(ParseTextFile(path)).To(HaveLen(2))
+		Expect(ParseTextFileAndLint(path, "bar")).To(ConsistOfMetrics(
+			Gauge(HaveName("bar"))))
+		Expect(ParseTextAndLint(strings.NewReader(textExposition))).To(HaveLen(2))
+	})
+
+	It("detects OpenMetrics text", func() {
+		Expect(isOpenMetricsText([]byte(textExposition))).To(BeFalse())
+		Expect(isOpenMetricsText([]byte(openMetricsExposition))).To(BeTrue())
+	})
+
+	When("things fail", Serial, func() {
+
+		var g Gomega
+		var msg string
+
+		BeforeEach(func() {
+			msg = ""
+			g = NewGomega(func(message string, callerSkip ...int) { msg = message })
+		})
+
+		It("reports line-numbered parse errors", func() {
+			parseText(g, strings.NewReader("# TYPE foo gauge\nfoo 1\nfoo{ 2\n"), "")
+			Expect(msg).To(MatchRegexp(`parsing metrics text failed\n(.*\n)*.*text format parsing error in line 3`))
+		})
+
+		It("reports line-numbered OpenMetrics parse errors", func() {
+			parseText(g, strings.NewReader("# TYPE foo gauge\nfoo 1\n# EOF\nfoo 2\n"), "")
+			Expect(msg).To(ContainSubstring("line 4"))
+		})
+
+		It("reports the file path with parse errors", func() {
+			path := filepath.Join(GinkgoT().TempDir(), "broken.prom")
+			Expect(os.WriteFile(path, []byte("foo{\n"), 0o644)).To(Succeed())
+			parseTextFile(g, path)
+			Expect(msg).To(ContainSubstring(path + ": text format parsing error in line 1"))
+		})
+
+		It("fails on a missing file", func() {
+			Expect(parseTextFile(g, filepath.Join(GinkgoT().TempDir(), "missing.prom"))).To(BeNil())
+			Expect(msg).To(ContainSubstring("opening metrics text file failed"))
+			msg = ""
+			Expect(parseTextFileAndLint(g, nil, filepath.Join(GinkgoT().TempDir(), "missing.prom"))).To(BeNil())
+			Expect(msg).To(ContainSubstring("opening metrics text file failed"))
+		})
+
+		It("fails given linting problems", func() {
+			parseTextAndLint(g, nil, strings.NewReader("# HELP foo foo.\n# TYPE foo counter\nfoo 42\n"), "")
+			Expect(msg).To(ContainSubstring("counter metrics should have \\\"_total\\\" suffix"))
+		})
+
+	})
+
+})
@@ -0,0 +1,120 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	prommodel "github.com/prometheus/client_model/go"
+)
+
+// NamingPolicy declaratively describes team-specific naming rules for metric
+// families that go beyond the generic promlint rules. Zero-valued fields don't
+// impose any restrictions. Plug a naming policy into linting using
+// [EnforceNamingPolicy], such as:
+//
+//	LintWith(EnforceNamingPolicy(NamingPolicy{
+//	    Namespaces:          []string{"myapp"},
+//	    RequiredUnits:       []string{"seconds", "bytes", "requests"},
+//	    DeniedLabels:        []string{"user_id"},
+//	    DeniedLabelValues:   map[string]*regexp.Regexp{
+//	        "path": regexp.MustCompile(`/\d+(/|$)`),
+//	    },
+//	    MaxLabelValueLength: 64,
+//	})).GatherAndLint(reg)
+type NamingPolicy struct {
+	// Namespaces lists the allowed namespaces; metric family names must start
+	// with one of these namespaces, followed by an underscore “_”.
+	Namespaces []string
+	// RequiredUnits lists the allowed units; metric family names must end in
+	// one of these units, preceded by an underscore “_” and optionally
+	// followed by “_total”.
+	RequiredUnits []string
+	// AllowedLabels lists the only allowed label names.
+	AllowedLabels []string
+	// DeniedLabels lists label names that must not be used.
+	DeniedLabels []string
+	// DeniedLabelValues maps label names to patterns that the values of these
+	// labels must not match, such as to catch IDs in paths.
+	DeniedLabelValues map[string]*regexp.Regexp
+	// HelpPrefix is the prefix every help text must start with.
+	HelpPrefix string
+	// MaxLabelValueLength is the maximum allowed length of label values in
+	// characters.
+	MaxLabelValueLength int
+}
+
+// EnforceNamingPolicy adds the passed naming policy to the lint rules, so that
+// policy violations are reported as linting problems.
+func EnforceNamingPolicy(policy NamingPolicy) LintOption {
+	return AddLintValidations(policy.Validate)
+}
+
+// Validate returns the violations of this naming policy by the passed metric
+// family. Validate is a promlint.Validation.
+func (p NamingPolicy) Validate(mf *prommodel.MetricFamily) []error {
+	var problems []error
+	if len(p.Namespaces) != 0 && !slices.ContainsFunc(p.Namespaces, func(namespace string) bool {
+		return strings.HasPrefix(mf.GetName(), namespace+"_")
+	}) {
+		problems = append(problems, fmt.Errorf("metric name should start with one of the namespaces %s",
+			strings.Join(p.Namespaces, ", ")))
+	}
+	if len(p.RequiredUnits) != 0 && !slices.ContainsFunc(p.RequiredUnits, func(unit string) bool {
+		name := strings.TrimSuffix(mf.GetName(), "_total")
+		return strings.HasSuffix(name, "_"+unit)
+	}) {
+		problems = append(problems, fmt.Errorf("metric name should end in one of the units %s",
+			strings.Join(p.RequiredUnits, ", ")))
+	}
+	if p.HelpPrefix != "" && !strings.HasPrefix(mf.GetHelp(), p.HelpPrefix) {
+		problems = append(problems, fmt.Errorf("help text should start with %q", p.HelpPrefix))
+	}
+	// report each violating label name only once per metric family, even if
+	// it appears in multiple metrics.
+	var notAllowed, denied, deniedValue, tooLong []string
+	for _, metric := range mf.GetMetric() {
+		for _, label := range metric.GetLabel() {
+			name := label.GetName()
+			if len(p.AllowedLabels) != 0 && !slices.Contains(p.AllowedLabels, name) &&
+				!slices.Contains(notAllowed, name) {
+				notAllowed = append(notAllowed, name)
+				problems = append(problems, fmt.Errorf("label %q is not among the allowed labels", name))
+			}
+			if slices.Contains(p.DeniedLabels, name) && !slices.Contains(denied, name) {
+				denied = append(denied, name)
+				problems = append(problems, fmt.Errorf("label %q is denied", name))
+			}
+			if re := p.DeniedLabelValues[name]; re != nil && re.MatchString(label.GetValue()) &&
+				!slices.Contains(deniedValue, name) {
+				deniedValue = append(deniedValue, name)
+				problems = append(problems, fmt.Errorf("value %q of label %q matches the denied pattern %q",
+					label.GetValue(), name, re.String()))
+			}
+			if p.MaxLabelValueLength > 0 &&
+				utf8.RuneCountInString(label.GetValue()) > p.MaxLabelValueLength &&
+				!slices.Contains(tooLong, name) {
+				tooLong = append(tooLong, name)
+				problems = append(problems, fmt.Errorf("value of label %q exceeds the maximum length of %d characters",
+					name, p.MaxLabelValueLength))
+			}
+		}
+	}
+	return problems
+}
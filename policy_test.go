@@ -0,0 +1,139 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	prommodel "github.com/prometheus/client_model/go"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("naming policies", func() {
+
+	family := func(name, help string, labels ...string) *prommodel.MetricFamily {
+		mf := &prommodel.MetricFamily{
+			Name: pstr(name),
+			Help: pstr(help),
+			Type: prommodel.MetricType_GAUGE.Enum(),
+		}
+		for idx := 0; idx < len(labels); idx += 2 {
+			mf.Metric = append(mf.Metric, &prommodel.Metric{
+				Label: []*prommodel.LabelPair{{Name: pstr(labels[idx]), Value: pstr(labels[idx+1])}},
+			})
+		}
+		return mf
+	}
+
+	It("doesn't restrict anything by default", func() {
+		Expect(NamingPolicy{}.Validate(family("foo", "", "bar", "baz"))).To(BeEmpty())
+	})
+
+	It("enforces namespaces and help prefixes", func() {
+		policy := NamingPolicy{
+			Namespaces: []string{"myapp", "yourapp"},
+			HelpPrefix: "[team]",
+		}
+		Expect(policy.Validate(family("myapp_foo", "[team] foo."))).To(BeEmpty())
+		Expect(policy.Validate(family("yourapp_foo", "[team] foo."))).To(BeEmpty())
+		Expect(policy.Validate(family("myappfoo", "foo."))).To(ConsistOf(
+			MatchError("metric name should start with one of the namespaces myapp, yourapp"),
+			MatchError(`help text should start with "[team]"`)))
+	})
+
+	It("enforces label names and value lengths", func() {
+		policy := NamingPolicy{
+			AllowedLabels:       []string{"code", "user_id"},
+			DeniedLabels:        []string{"user_id"},
+			MaxLabelValueLength: 4,
+		}
+		Expect(policy.Validate(family("foo", "",
+			"code", "200",
+			"code", "200000",
+			"code", "ÄÖÜß",
+			"user_id", "42",
+			"user_id", "43",
+			"method", "GET"))).To(HaveExactElements(
+			MatchError(`value of label "code" exceeds the maximum length of 4 characters`),
+			MatchError(`label "user_id" is denied`),
+			MatchError(`label "method" is not among the allowed labels`)))
+	})
+
+	It("enforces units", func() {
+		policy := NamingPolicy{
+			RequiredUnits: []string{"seconds", "bytes"},
+		}
+		Expect(policy.Validate(family("foo_seconds", ""))).To(BeEmpty())
+		Expect(policy.Validate(family("foo_bytes_total", ""))).To(BeEmpty())
+		Expect(policy.Validate(family("foo_seconds_total", ""))).To(BeEmpty())
+		Expect(policy.Validate(family("foo_milliseconds", ""))).To(ConsistOf(
+			MatchError("metric name should end in one of the units seconds, bytes")))
+		Expect(policy.Validate(family("foo_total", ""))).To(ConsistOf(
+			MatchError("metric name should end in one of the units seconds, bytes")))
+		Expect(policy.Validate(family("seconds", ""))).To(ConsistOf(
+			MatchError("metric name should end in one of the units seconds, bytes")))
+		Expect(policy.Validate(family("foo_seconds_count", ""))).To(ConsistOf(
+			MatchError("metric name should end in one of the units seconds, bytes")))
+	})
+
+	It("enforces label values", func() {
+		policy := NamingPolicy{
+			DeniedLabelValues: map[string]*regexp.Regexp{
+				"path":   regexp.MustCompile(`/\d+(/|$)`),
+				"method": nil,
+			},
+		}
+		Expect(policy.Validate(family("foo", "",
+			"path", "/users",
+			"path", "/users/42",
+			"path", "/users/43/profile",
+			"method", "GET",
+			"code", "/42"))).To(HaveExactElements(
+			MatchError(`value "/users/42" of label "path" matches the denied pattern "/\\d+(/|$)"`)))
+	})
+
+	When("linting", Serial, func() {
+
+		var g Gomega
+		var msg string
+
+		BeforeEach(func() {
+			msg = ""
+			g = NewGomega(func(message string, callerSkip ...int) { msg = message })
+		})
+
+		It("reports policy violations as linting problems", func() {
+			c := prometheus.NewCounterVec(prometheus.CounterOpts{
+				Name: "foo_total",
+				Help: "foo.",
+			}, []string{"user_id"})
+			c.WithLabelValues(strings.Repeat("x", 10)).Inc()
+			For(g).LintWith(EnforceNamingPolicy(NamingPolicy{
+				Namespaces:   []string{"myapp"},
+				DeniedLabels: []string{"user_id"},
+			})).CollectAndLint(c)
+			Expect(msg).To(And(
+				ContainSubstring("linting problems"),
+				ContainSubstring("metric name should start with one of the namespaces myapp"),
+				ContainSubstring(`label \"user_id\" is denied`)))
+		})
+
+	})
+
+})
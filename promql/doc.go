@@ -0,0 +1,14 @@
+/*
+Package promql evaluates PromQL expressions as well as alerting and recording
+rules locally against metric families, such as those returned by
+[pyrotest.CollectAndLint] and [pyrotest.GatherAndLint], without the need for a
+Prometheus server.
+
+# Separate Module
+
+This package is a separate Go module, as it depends on the Prometheus server
+module for its PromQL engine, with its many transitive dependencies. Users of
+only the metric matchers of package pyrotest thus don't inherit these
+dependencies.
+*/
+package promql
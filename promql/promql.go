@@ -0,0 +1,213 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package promql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	prommodel "github.com/prometheus/client_model/go"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/thediveo/pyrotest"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+)
+
+// queryTimeout limits the evaluation time of PromQL expressions.
+const queryTimeout = 30 * time.Second
+
+// queryEngine returns the PromQL engine shared by all local query evaluations.
+var queryEngine = sync.OnceValue(func() *promql.Engine {
+	return promql.NewEngine(promql.EngineOpts{
+		MaxSamples:           50_000_000,
+		Timeout:              queryTimeout,
+		EnableAtModifier:     true,
+		EnableNegativeOffset: true,
+	})
+})
+
+// EvaluatePromQL returns a transform function that evaluates the passed instant
+// PromQL expression against a [pyrotest.MetricsFamilies] map, returning the
+// result again as a [pyrotest.MetricsFamilies] map. Use EvaluatePromQL with
+// Gomega's WithTransform, or use [SatisfyQuery] instead.
+//
+// All samples of the metric families are considered to have been taken at the
+// time of evaluation, ignoring any metric timestamps. Histograms and summaries
+// are represented by their classic “_bucket”, “_sum”, “_count”, and quantile
+// series, similar to what Prometheus scrapes from the text exposition format.
+//
+// The result consists of untyped metrics grouped into metric families by their
+// metric names. Results without a metric name, such as aggregations and scalar
+// results, are returned in the metric family with the empty name “”. Use
+// [pyrotest.Untyped] or [pyrotest.AnyMetric] together with [pyrotest.HaveLabel]
+// and [pyrotest.HaveSampleValue] to match results, such as:
+//
+//	Expect(families).To(WithTransform(
+//	    EvaluatePromQL(`sum by (code) (http_requests_total)`),
+//	    ContainMetrics(Untyped(HaveLabel("code=200"), HaveSampleValue(3)))))
+func EvaluatePromQL(expr string) func(pyrotest.MetricsFamilies) (pyrotest.MetricsFamilies, error) {
+	return func(families pyrotest.MetricsFamilies) (pyrotest.MetricsFamilies, error) {
+		if families == nil {
+			return nil, errors.New("EvaluatePromQL expects a non-nil map of metric families")
+		}
+		store := newSampleStore()
+		now := time.Now()
+		store.addFamilies(families, now.UnixMilli())
+		return evaluatePromQL(store, expr, now)
+	}
+}
+
+// evaluatePromQL evaluates the passed instant PromQL expression against the
+// sample store at the specified time, returning the result as a metric
+// families map.
+func evaluatePromQL(store *sampleStore, expr string, ts time.Time) (pyrotest.MetricsFamilies, error) {
+	ctx := context.Background()
+	query, err := queryEngine().NewInstantQuery(ctx, store, nil, expr, ts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PromQL expression: %w", err)
+	}
+	defer query.Close()
+	result := query.Exec(ctx)
+	if result.Err != nil {
+		return nil, fmt.Errorf("evaluating PromQL expression failed: %w", result.Err)
+	}
+	switch value := result.Value.(type) {
+	case promql.Vector:
+		return vectorFamilies(value), nil
+	case promql.Scalar:
+		return vectorFamilies(promql.Vector{{F: value.V}}), nil
+	}
+	return nil, fmt.Errorf("unsupported PromQL result type %q, expecting an instant vector or scalar",
+		result.Value.Type())
+}
+
+// vectorFamilies returns the samples of the passed vector as untyped metrics,
+// grouped into metric families by their metric names.
+func vectorFamilies(vector promql.Vector) pyrotest.MetricsFamilies {
+	families := pyrotest.MetricsFamilies{}
+	for _, sample := range vector {
+		name := sample.Metric.Get(labels.MetricName)
+		family, ok := families[name]
+		if !ok {
+			family = &prommodel.MetricFamily{
+				Name: proto.String(name),
+				Type: prommodel.MetricType_UNTYPED.Enum(),
+			}
+			families[name] = family
+		}
+		metric := &prommodel.Metric{
+			Untyped: &prommodel.Untyped{Value: proto.Float64(sample.F)},
+		}
+		sample.Metric.Range(func(label labels.Label) {
+			if label.Name == labels.MetricName {
+				return
+			}
+			metric.Label = append(metric.Label, &prommodel.LabelPair{
+				Name:  proto.String(label.Name),
+				Value: proto.String(label.Value),
+			})
+		})
+		family.Metric = append(family.Metric, metric)
+	}
+	return families
+}
+
+// queryResultString returns the passed query result in a concise textual
+// form, one sample per line.
+func queryResultString(result pyrotest.MetricsFamilies) string {
+	if len(result) == 0 {
+		return "(empty result)"
+	}
+	var lines []string
+	timeseries, _ := pyrotest.Timeseries(result)
+	for _, ts := range timeseries {
+		lines = append(lines, ts.String())
+	}
+	slices.Sort(lines)
+	return strings.Join(lines, "\n")
+}
+
+// ----
+
+// SatisfyQueryMatcher is a [types.GomegaMatcher] that evaluates an instant
+// PromQL expression against an actual [pyrotest.MetricsFamilies] map and then
+// matches the result against another matcher.
+type SatisfyQueryMatcher struct {
+	Expr    string
+	Matcher types.GomegaMatcher
+	result  pyrotest.MetricsFamilies
+}
+
+var (
+	_ types.GomegaMatcher   = (*SatisfyQueryMatcher)(nil)
+	_ format.GomegaStringer = (*SatisfyQueryMatcher)(nil)
+)
+
+// SatisfyQuery succeeds if actual is a [pyrotest.MetricsFamilies] map and the
+// result of evaluating the passed instant PromQL expression against it
+// satisfies the passed matcher. See [EvaluatePromQL] for details about how the result is
+// represented. For instance:
+//
+//	Expect(families).To(SatisfyQuery(
+//	    `sum by (code) (http_requests_total) > 3`,
+//	    ContainMetrics(Untyped(HaveLabel("code=200")))))
+//
+// Please note that Gomega matchers such as BeEmpty and HaveLen can be used to
+// check whether a query returned any results at all.
+func SatisfyQuery(expr string, matcher types.GomegaMatcher) *SatisfyQueryMatcher {
+	return &SatisfyQueryMatcher{
+		Expr:    expr,
+		Matcher: matcher,
+	}
+}
+
+func (m *SatisfyQueryMatcher) GomegaString() string {
+	return fmt.Sprintf("query %q", m.Expr)
+}
+
+func (m *SatisfyQueryMatcher) Match(actual any) (bool, error) {
+	families, ok := actual.(pyrotest.MetricsFamilies)
+	if !ok || families == nil {
+		return false, fmt.Errorf(
+			"SatisfyQuery matcher expects a non-nil map of metric families, indexed by their names.  Got:\n%s",
+			format.Object(actual, 1))
+	}
+	result, err := EvaluatePromQL(m.Expr)(families)
+	if err != nil {
+		return false, err
+	}
+	m.result = result
+	return m.Matcher.Match(result)
+}
+
+func (m *SatisfyQueryMatcher) FailureMessage(actual any) string {
+	return fmt.Sprintf("Expected query %q with result\n%s\nto satisfy matcher:\n%s",
+		m.Expr, format.IndentString(queryResultString(m.result), 1),
+		m.Matcher.FailureMessage(m.result))
+}
+
+func (m *SatisfyQueryMatcher) NegatedFailureMessage(actual any) string {
+	return fmt.Sprintf("Expected query %q with result\n%s\nnot to satisfy matcher:\n%s",
+		m.Expr, format.IndentString(queryResultString(m.result), 1),
+		m.Matcher.NegatedFailureMessage(m.result))
+}
@@ -0,0 +1,131 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package promql
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/thediveo/pyrotest"
+)
+
+var _ = Describe("PromQL queries", func() {
+
+	var families MetricsFamilies
+
+	BeforeEach(func() {
+		c := prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "requests.",
+		}, []string{"code", "method"})
+		c.WithLabelValues("200", "GET").Add(3)
+		c.WithLabelValues("200", "POST").Add(2)
+		c.WithLabelValues("404", "GET").Add(1)
+		h := prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "duration_seconds",
+			Help:    "durations.",
+			Buckets: []float64{1, 2, 4},
+		})
+		for _, v := range []float64{0.5, 1.5, 1.5, 3} {
+			h.Observe(v)
+		}
+		reg := prometheus.NewPedanticRegistry()
+		Expect(reg.Register(c)).To(Succeed())
+		Expect(reg.Register(h)).To(Succeed())
+		families = GatherAndLint(reg)
+	})
+
+	It("evaluates instant vector queries", func() {
+		Expect(families).To(SatisfyQuery(`sum by (code) (http_requests_total) > 3`,
+			ConsistOfMetrics(Untyped(HaveName(""), HaveExactLabels("code=200"), HaveSampleValue(5)))))
+		Expect(families).To(WithTransform(EvaluatePromQL(`http_requests_total{method="GET"}`),
+			And(
+				HaveLen(1),
+				ContainMetrics(
+					Untyped(HaveName("http_requests_total"), HaveLabel("code=404"), HaveSampleValue(1))))))
+		Expect(families).To(SatisfyQuery(`http_requests_total{code="500"}`, BeEmpty()))
+	})
+
+	It("evaluates histogram and scalar queries", func() {
+		Expect(families).To(SatisfyQuery(`duration_seconds_bucket{le="+Inf"}`,
+			ContainMetrics(Untyped(HaveSampleValue(4)))))
+		Expect(families).To(SatisfyQuery(`histogram_quantile(0.5, duration_seconds_bucket)`,
+			ContainMetrics(Untyped(HaveSampleValue(BeNumerically("~", 1.5, 1e-9))))))
+		Expect(families).To(SatisfyQuery(`scalar(sum(http_requests_total)) * 2`,
+			ConsistOfMetrics(Untyped(HaveName(""), HaveSampleValue(12)))))
+	})
+
+	It("reports errors", func() {
+		Expect(EvaluatePromQL("foo")(nil)).Error().To(HaveOccurred())
+		Expect(EvaluatePromQL("foo{")(families)).Error().To(MatchError(
+			HavePrefix("invalid PromQL expression")))
+		Expect(EvaluatePromQL("http_requests_total[5m]")(families)).Error().To(MatchError(
+			`unsupported PromQL result type "matrix", expecting an instant vector or scalar`))
+		Expect(SatisfyQuery("foo", BeEmpty()).Match(nil)).Error().To(HaveOccurred())
+		Expect(SatisfyQuery("foo{", BeEmpty()).Match(families)).Error().To(HaveOccurred())
+	})
+
+	It("reports query results in failure messages", func() {
+		m := SatisfyQuery(`sum by (code) (http_requests_total)`, BeEmpty())
+		Expect(m.GomegaString()).To(Equal(`query "sum by (code) (http_requests_total)"`))
+		Expect(m.Match(families)).To(BeFalse())
+		Expect(m.FailureMessage(families)).To(HavePrefix(
+			"Expected query \"sum by (code) (http_requests_total)\" with result\n" +
+				"    {code=\"200\"} 5\n" +
+				"    {code=\"404\"} 1\n" +
+				"to satisfy matcher:\n"))
+		m = SatisfyQuery(`http_requests_total{code="500"}`, BeEmpty())
+		Expect(m.Match(families)).To(BeTrue())
+		Expect(m.NegatedFailureMessage(families)).To(HavePrefix(
+			"Expected query \"http_requests_total{code=\\\"500\\\"}\" with result\n" +
+				"    (empty result)\n" +
+				"not to satisfy matcher:\n"))
+	})
+
+	It("stores and queries samples", func() {
+		store := newSampleStore()
+		foo := labels.FromStrings("__name__", "foo", "a", "1")
+		store.add(foo, 2000, 2)
+		store.add(foo, 1000, 1)
+		store.add(foo, 2000, 42)
+		store.add(labels.FromStrings("__name__", "bar", "b", "2"), 1000, 1)
+		Expect(store.series[foo.String()].samples).To(HaveExactElements(
+			floatSample{t: 1000, f: 1}, floatSample{t: 2000, f: 42}))
+
+		q, err := store.Querier(0, 1500)
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = q.Close() }()
+		ctx := context.Background()
+		Expect(q.LabelNames(ctx, nil)).To(Equal([]string{"__name__", "a", "b"}))
+		Expect(q.LabelValues(ctx, "__name__", nil)).To(Equal([]string{"bar", "foo"}))
+		Expect(q.LabelValues(ctx, "__name__", nil,
+			labels.MustNewMatcher(labels.MatchEqual, "a", "1"))).To(Equal([]string{"foo"}))
+		set := q.Select(ctx, true, nil)
+		Expect(set.Next()).To(BeTrue())
+		Expect(set.At().Labels().Get("__name__")).To(Equal("bar"))
+		Expect(set.Next()).To(BeTrue())
+		Expect(set.Next()).To(BeFalse())
+		Expect(set.Err()).NotTo(HaveOccurred())
+		Expect(set.Warnings()).To(BeEmpty())
+
+		q, _ = store.Querier(3000, 4000)
+		Expect(q.Select(ctx, true, nil).Next()).To(BeFalse())
+	})
+
+})
@@ -0,0 +1,367 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package promql
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	prommodel "github.com/prometheus/client_model/go"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/rulefmt"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/rules"
+	"github.com/thediveo/pyrotest"
+	"google.golang.org/protobuf/proto"
+
+	gi "github.com/onsi/ginkgo/v2"
+	gom "github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+)
+
+// rulesEpoch is the simulated time of the first snapshot when evaluating
+// rules.
+var rulesEpoch = time.Unix(0, 0).UTC()
+
+// RuleEvaluation is the outcome of evaluating alerting and recording rules over
+// a sequence of metric families snapshots, as returned by [EvaluateRules].
+type RuleEvaluation struct {
+	// Alerts lists the pending and firing alerts after the final evaluation,
+	// sorted by alert name and labels.
+	Alerts []Alert
+	// Recorded contains the results of the recording rules from the final
+	// evaluation as untyped metrics.
+	Recorded pyrotest.MetricsFamilies
+}
+
+// Alert is a single pending or firing alert instance.
+type Alert struct {
+	Name        string
+	State       string // either “pending” or “firing”.
+	Labels      prometheus.Labels
+	Annotations prometheus.Labels
+	Value       float64   // value at the final evaluation.
+	ActiveAt    time.Time // simulated time the alert became active.
+}
+
+// String returns the alert in a concise textual form.
+func (a Alert) String() string {
+	return fmt.Sprintf("%s %s%s %s", a.State, a.Name,
+		labels.FromMap(a.Labels).String(), pyrotest.FormatFloat(a.Value))
+}
+
+// EvaluateRules loads the alerting and recording rules from the specified
+// rules YAML file and evaluates them over the passed sequence of metric
+// families snapshots. The snapshots are considered to have been taken at
+// simulated points in time the passed interval apart, starting at the Unix
+// epoch. After adding the samples of each snapshot, all rules are evaluated in
+// the order of their rule groups and the rules therein; recording rule
+// results are available to subsequent rules as well as subsequent
+// evaluations. Just like Prometheus, series disappearing from a snapshot or
+// from the rule results are marked stale, so they immediately vanish instead of
+// lingering for the lookback delta.
+//
+// If the rules file cannot be loaded or rules fail to evaluate, EvaluateRules
+// fails the current test. Use matchers such as [HaveFiringAlert] on the
+// returned [RuleEvaluation], such as:
+//
+//	Expect(EvaluateRules("alerts.yaml", time.Minute,
+//	    CollectAndLint(coll), CollectAndLint(coll), CollectAndLint(coll))).
+//	    To(HaveFiringAlert("HighErrorRate", HaveLabel("severity=page")))
+func EvaluateRules(rulesFile string, interval time.Duration, snapshots ...pyrotest.MetricsFamilies) RuleEvaluation {
+	gi.GinkgoHelper()
+	return evaluateRules(gom.Default, rulesFile, interval, snapshots...)
+}
+
+// EvaluateRulesWith works like [EvaluateRules], but uses the passed Gomega
+// instance, such as one created using [gom.NewWithT] for use with plain “go
+// test” tests, instead of Ginkgo's default Gomega.
+func EvaluateRulesWith(gomega types.Gomega, rulesFile string, interval time.Duration, snapshots ...pyrotest.MetricsFamilies) RuleEvaluation {
+	gi.GinkgoHelper()
+	pyrotest.THelper(gomega)()
+	return evaluateRules(gomega, rulesFile, interval, snapshots...)
+}
+
+func evaluateRules(gomega types.Gomega, rulesFile string, interval time.Duration, snapshots ...pyrotest.MetricsFamilies) RuleEvaluation {
+	gi.GinkgoHelper()
+	pyrotest.THelper(gomega)()
+	evaluation, err := evaluateRulesFile(rulesFile, interval, snapshots)
+	gomega.Expect(err).NotTo(gom.HaveOccurred(), "evaluating rules failed")
+	return evaluation
+}
+
+// evaluateRulesFile loads the rules from the specified file and evaluates them
+// over the passed snapshots.
+func evaluateRulesFile(rulesFile string, interval time.Duration, snapshots []pyrotest.MetricsFamilies) (RuleEvaluation, error) {
+	groups, errs := rulefmt.ParseFile(rulesFile, false)
+	if len(errs) != 0 {
+		return RuleEvaluation{}, errors.Join(errs...)
+	}
+	var allRules []rules.Rule
+	for _, group := range groups.Groups {
+		for _, node := range group.Rules {
+			rule, err := newRule(group, node)
+			if err != nil {
+				return RuleEvaluation{}, fmt.Errorf("%s: group %q: %w", rulesFile, group.Name, err)
+			}
+			allRules = append(allRules, rule)
+		}
+	}
+
+	store := newSampleStore()
+	query := rules.EngineQueryFunc(queryEngine(), store)
+	ctx := context.Background()
+	var recorded promql.Vector
+	// series of the previous evaluation round that are to be marked stale
+	// when they disappear, so that they don't linger for the lookback delta.
+	var scraped, evaluated map[string]labels.Labels
+	for idx, snapshot := range snapshots {
+		ts := rulesEpoch.Add(time.Duration(idx) * interval)
+		current := store.addFamilies(snapshot, ts.UnixMilli())
+		store.markStale(scraped, current, ts.UnixMilli())
+		scraped = current
+		// several recording rules might record into the same metric name, so
+		// we need to keep all their results of this evaluation round.
+		recorded = recorded[:0]
+		current = map[string]labels.Labels{}
+		for _, rule := range allRules {
+			vector, err := rule.Eval(ctx, 0, ts, query, nil, 0)
+			if err != nil {
+				return RuleEvaluation{}, fmt.Errorf("evaluating rule %q at %s failed: %w",
+					rule.Name(), ts.Sub(rulesEpoch), err)
+			}
+			for _, sample := range vector {
+				store.add(sample.Metric, sample.T, sample.F)
+				current[sample.Metric.String()] = sample.Metric
+			}
+			if _, ok := rule.(*rules.RecordingRule); ok {
+				recorded = append(recorded, vector...)
+			}
+		}
+		store.markStale(evaluated, current, ts.UnixMilli())
+		evaluated = current
+	}
+
+	evaluation := RuleEvaluation{Recorded: vectorFamilies(recorded)}
+	for _, rule := range allRules {
+		alerting, ok := rule.(*rules.AlertingRule)
+		if !ok {
+			continue
+		}
+		for _, alert := range alerting.ActiveAlerts() {
+			evaluation.Alerts = append(evaluation.Alerts, Alert{
+				Name:        alerting.Name(),
+				State:       alert.State.String(),
+				Labels:      alert.Labels.Map(),
+				Annotations: alert.Annotations.Map(),
+				Value:       alert.Value,
+				ActiveAt:    alert.ActiveAt,
+			})
+		}
+	}
+	slices.SortFunc(evaluation.Alerts, func(a, b Alert) int {
+		return strings.Compare(a.String(), b.String())
+	})
+	return evaluation, nil
+}
+
+// newRule returns a new alerting or recording rule for the passed rule node,
+// taking the group labels into account.
+func newRule(group rulefmt.RuleGroup, node rulefmt.RuleNode) (rules.Rule, error) {
+	expr, err := parser.ParseExpr(node.Expr.Value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule expression %q: %w", node.Expr.Value, err)
+	}
+	lbls := labels.FromMap(group.Labels)
+	if len(node.Labels) != 0 {
+		b := labels.NewBuilder(lbls)
+		for name, value := range node.Labels {
+			b.Set(name, value)
+		}
+		lbls = b.Labels()
+	}
+	if node.Record.Value != "" {
+		return rules.NewRecordingRule(node.Record.Value, expr, lbls), nil
+	}
+	return rules.NewAlertingRule(node.Alert.Value, expr,
+		time.Duration(node.For), time.Duration(node.KeepFiringFor),
+		lbls, labels.FromMap(node.Annotations), labels.EmptyLabels(), "",
+		true, slog.New(slog.DiscardHandler)), nil
+}
+
+// labelPairs returns the passed labels as label pairs sorted by name.
+func labelPairs(lbls prometheus.Labels) []*prommodel.LabelPair {
+	pairs := make([]*prommodel.LabelPair, 0, len(lbls))
+	for _, name := range slices.Sorted(maps.Keys(lbls)) {
+		pairs = append(pairs, &prommodel.LabelPair{
+			Name:  proto.String(name),
+			Value: proto.String(lbls[name]),
+		})
+	}
+	return pairs
+}
+
+// ----
+
+// AlertMatcher is a [types.GomegaMatcher] that succeeds if a [RuleEvaluation]
+// contains an alert in a specific state with a matching name and labels.
+type AlertMatcher struct {
+	state    string
+	name     types.GomegaMatcher
+	expected any // original expected name for error reporting.
+	labels   []pyrotest.MetricPropertyMatcher
+}
+
+var (
+	_ types.GomegaMatcher   = (*AlertMatcher)(nil)
+	_ format.GomegaStringer = (*AlertMatcher)(nil)
+)
+
+// HaveFiringAlert succeeds if actual is a [RuleEvaluation] with a firing alert
+// with a name either equal to the passed string or matching the passed
+// GomegaMatcher. Optionally, the alert labels must satisfy the passed label
+// matchers [pyrotest.HaveLabel], [pyrotest.HaveLabelWithValue], and
+// [pyrotest.NotHaveLabel]. Please note that alert labels include the labels of
+// the alert expression result, as well as the labels defined by the alerting
+// rule and its group. The alert value can be matched using
+// [pyrotest.HaveSampleValue].
+func HaveFiringAlert(name any, labels ...pyrotest.MetricPropertyMatcher) *AlertMatcher {
+	return newAlertMatcher(rules.StateFiring.String(), name, labels)
+}
+
+// HavePendingAlert succeeds if actual is a [RuleEvaluation] with a pending
+// alert, that is, an active alert that hasn't yet been active for its “for”
+// duration. See [HaveFiringAlert] for details about name and label matching.
+func HavePendingAlert(name any, labels ...pyrotest.MetricPropertyMatcher) *AlertMatcher {
+	return newAlertMatcher(rules.StatePending.String(), name, labels)
+}
+
+func newAlertMatcher(state string, name any, labels []pyrotest.MetricPropertyMatcher) *AlertMatcher {
+	m := &AlertMatcher{
+		state:    state,
+		expected: name,
+		labels:   labels,
+	}
+	switch n := name.(type) {
+	case string:
+		m.name = gom.Equal(n)
+	case types.GomegaMatcher:
+		m.name = n
+	}
+	return m
+}
+
+func (m *AlertMatcher) GomegaString() string {
+	var s strings.Builder
+	if name, ok := m.expected.(string); ok {
+		fmt.Fprintf(&s, "%s alert: %s", m.state, name)
+	} else {
+		fmt.Fprintf(&s, "%s alert: %s", m.state, format.Object(m.expected, 1))
+	}
+	for _, label := range m.labels {
+		s.WriteRune('\n')
+		if gs, ok := label.(format.GomegaStringer); ok {
+			s.WriteString(format.IndentString(gs.GomegaString(), 1))
+			continue
+		}
+		s.WriteString(format.Object(label, 1))
+	}
+	return s.String()
+}
+
+func (m *AlertMatcher) Match(actual any) (bool, error) {
+	evaluation, ok := actual.(RuleEvaluation)
+	if !ok {
+		return false, fmt.Errorf("%s matcher expects a RuleEvaluation.  Got:\n%s",
+			m.matcherName(), format.Object(actual, 1))
+	}
+	if m.name == nil {
+		return false, errors.New(format.Message(
+			m.expected, "to be either a string or GomegaMatcher"))
+	}
+	for _, alert := range evaluation.Alerts {
+		if alert.State != m.state {
+			continue
+		}
+		if ok, err := m.name.Match(alert.Name); err != nil || !ok {
+			if err != nil {
+				return false, err
+			}
+			continue
+		}
+		if ok, err := m.matchLabels(alert); err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// matchLabels matches the labels and value of the passed alert, using the
+// label matcher semantics of pyrotest by representing the alert as an untyped
+// metric.
+func (m *AlertMatcher) matchLabels(alert Alert) (bool, error) {
+	families := pyrotest.MetricsFamilies{
+		alert.Name: {
+			Name: proto.String(alert.Name),
+			Type: prommodel.MetricType_UNTYPED.Enum(),
+			Metric: []*prommodel.Metric{{
+				Label:   labelPairs(alert.Labels),
+				Untyped: &prommodel.Untyped{Value: proto.Float64(alert.Value)},
+			}},
+		},
+	}
+	return pyrotest.ContainMetrics(pyrotest.AnyMetric(m.labels...)).Match(families)
+}
+
+func (m *AlertMatcher) FailureMessage(actual any) string {
+	return fmt.Sprintf("Expected %s\nto have %s", alertsString(actual), m.GomegaString())
+}
+
+func (m *AlertMatcher) NegatedFailureMessage(actual any) string {
+	return fmt.Sprintf("Expected %s\nnot to have %s", alertsString(actual), m.GomegaString())
+}
+
+func (m *AlertMatcher) matcherName() string {
+	if m.state == rules.StatePending.String() {
+		return "HavePendingAlert"
+	}
+	return "HaveFiringAlert"
+}
+
+// alertsString returns the alerts of a RuleEvaluation, one per line.
+func alertsString(actual any) string {
+	evaluation, ok := actual.(RuleEvaluation)
+	if !ok {
+		return format.Object(actual, 1)
+	}
+	if len(evaluation.Alerts) == 0 {
+		return "no active alerts"
+	}
+	var s strings.Builder
+	s.WriteString("active alerts:")
+	for _, alert := range evaluation.Alerts {
+		s.WriteString("\n" + format.Indent + alert.String())
+	}
+	return s.String()
+}
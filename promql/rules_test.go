@@ -0,0 +1,188 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package promql
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	. "github.com/thediveo/pyrotest"
+)
+
+const rulesYAML = `groups:
+- name: jobs
+  labels:
+    team: infra
+  rules:
+  - record: job:jobs_failed:increase2m
+    expr: sum by (job) (increase(jobs_failed_total[2m]))
+  - alert: JobsFailing
+    expr: job:jobs_failed:increase2m > 0
+    for: 2m
+    labels:
+      severity: page
+    annotations:
+      summary: "{{ $labels.job }} is failing"
+`
+
+var _ = Describe("evaluating rules", func() {
+
+	var rulesFile string
+
+	BeforeEach(func() {
+		rulesFile = filepath.Join(GinkgoT().TempDir(), "rules.yaml")
+		Expect(os.WriteFile(rulesFile, []byte(rulesYAML), 0o644)).To(Succeed())
+	})
+
+	snapshots := func(failures ...float64) []MetricsFamilies {
+		reg := prometheus.NewPedanticRegistry()
+		c := prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "jobs_failed_total",
+			Help: "Number of failed jobs.",
+		}, []string{"job"})
+		reg.MustRegister(c)
+		c.WithLabelValues("backup")
+		var snaps []MetricsFamilies
+		for _, f := range failures {
+			c.WithLabelValues("backup").Add(f)
+			snaps = append(snaps, GatherAndLint(reg))
+		}
+		return snaps
+	}
+
+	It("records and fires alerts", func() {
+		evaluation := EvaluateRules(rulesFile, time.Minute, snapshots(0, 0, 1, 1, 1, 1)...)
+		Expect(evaluation.Recorded).To(ContainMetrics(
+			Untyped(HaveName("job:jobs_failed:increase2m"),
+				HaveLabel("job=backup"), HaveLabel("team=infra"))))
+		Expect(evaluation).To(HaveFiringAlert("JobsFailing",
+			HaveLabel("job=backup"), HaveLabel("severity=page"), HaveLabel("team=infra")))
+		Expect(evaluation).To(HaveFiringAlert(HavePrefix("Jobs")))
+		Expect(evaluation).NotTo(HaveFiringAlert("JobsFailing", NotHaveLabel("job")))
+		Expect(evaluation).NotTo(HavePendingAlert("JobsFailing"))
+		Expect(evaluation.Alerts).To(ConsistOf(And(
+			HaveField("Annotations", HaveKeyWithValue("summary", "backup is failing")),
+			HaveField("ActiveAt", Equal(rulesEpoch.Add(2*time.Minute))))))
+	})
+
+	It("reports pending alerts", func() {
+		evaluation := EvaluateRules(rulesFile, time.Minute, snapshots(0, 0, 1, 1)...)
+		Expect(evaluation).To(HavePendingAlert("JobsFailing", HaveLabel("job=backup")))
+		Expect(evaluation).NotTo(HaveFiringAlert("JobsFailing"))
+		Expect(EvaluateRules(rulesFile, time.Minute, snapshots(0, 0)...)).NotTo(
+			HavePendingAlert("JobsFailing"))
+	})
+
+	It("reports active alerts in failure messages", func() {
+		evaluation := EvaluateRules(rulesFile, time.Minute, snapshots(0, 0, 1, 1)...)
+		m := HaveFiringAlert("JobsFailing", HaveLabel("job=backup"))
+		Expect(m.Match(evaluation)).To(BeFalse())
+		Expect(m.FailureMessage(evaluation)).To(MatchRegexp(
+			`(?s)^Expected active alerts:\n    pending JobsFailing\{alertname="JobsFailing", job="backup", severity="page", team="infra"\} 2\nto have firing alert: JobsFailing\n.*job.*backup`))
+		Expect(m.NegatedFailureMessage(RuleEvaluation{})).To(HavePrefix(
+			"Expected no active alerts\nnot to have firing alert: JobsFailing"))
+	})
+
+	It("keeps the results of recording rules with the same name", func() {
+		Expect(os.WriteFile(rulesFile, []byte(`groups:
+- name: jobs
+  rules:
+  - record: job:jobs_failed:total
+    expr: sum(jobs_failed_total)
+    labels:
+      scope: all
+  - record: job:jobs_failed:total
+    expr: sum by (job) (jobs_failed_total)
+    labels:
+      scope: job
+`), 0o644)).To(Succeed())
+		evaluation := EvaluateRules(rulesFile, time.Minute, snapshots(1, 2)...)
+		Expect(evaluation.Recorded).To(HaveLen(1))
+		Expect(evaluation.Recorded).To(ContainMetrics(
+			Untyped(HaveName("job:jobs_failed:total"), HaveExactLabels("scope=all"), HaveSampleValue(3)),
+			Untyped(HaveName("job:jobs_failed:total"), HaveExactLabels("scope=job", "job=backup"), HaveSampleValue(3))))
+		Expect(evaluation.Recorded).To(ContainMetrics(
+			Untyped(HaveName("job:jobs_failed:total"), HaveTimeseriesCount(2))))
+	})
+
+	It("resolves alerts when their series disappear", func() {
+		Expect(os.WriteFile(rulesFile, []byte(`groups:
+- name: queues
+  rules:
+  - record: queue:stuck:sum
+    expr: sum by (queue) (queue_stuck)
+  - alert: QueueStuck
+    expr: queue_stuck > 0
+`), 0o644)).To(Succeed())
+		reg := prometheus.NewPedanticRegistry()
+		stuck := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "queue_stuck",
+			Help: "Stuck queues.",
+		}, []string{"queue"})
+		reg.MustRegister(stuck)
+		stuck.WithLabelValues("jobs").Set(1)
+		before := GatherAndLint(reg)
+		Expect(EvaluateRules(rulesFile, time.Minute, before, before)).To(
+			HaveFiringAlert("QueueStuck", HaveLabel("queue=jobs")))
+
+		stuck.DeleteLabelValues("jobs")
+		after := GatherAndLint(reg)
+		evaluation := EvaluateRules(rulesFile, time.Minute, before, before, after)
+		Expect(evaluation.Alerts).To(BeEmpty())
+		Expect(evaluation.Recorded).To(BeEmpty())
+	})
+
+	It("rejects invalid actual values", func() {
+		Expect(HaveFiringAlert("foo").Match(42)).Error().To(MatchError(
+			ContainSubstring("HaveFiringAlert matcher expects a RuleEvaluation")))
+		Expect(HavePendingAlert(42).Match(RuleEvaluation{
+			Alerts: []Alert{{Name: "foo", State: "pending"}},
+		})).Error().To(MatchError(ContainSubstring("to be either a string or GomegaMatcher")))
+	})
+
+	When("things fail", Serial, func() {
+
+		var g Gomega
+		var msg string
+
+		BeforeEach(func() {
+			msg = ""
+			g = NewGomega(func(message string, callerSkip ...int) { msg = message })
+		})
+
+		It("fails on missing rules files", func() {
+			EvaluateRulesWith(g, filepath.Join(GinkgoT().TempDir(), "missing.yaml"), time.Minute)
+			Expect(msg).To(ContainSubstring("evaluating rules failed"))
+		})
+
+		It("fails on invalid rules", func() {
+			Expect(os.WriteFile(rulesFile, []byte(`groups:
+- name: broken
+  rules:
+  - record: foo
+    expr: sum(
+`), 0o644)).To(Succeed())
+			evaluateRules(g, rulesFile, time.Minute)
+			Expect(msg).To(ContainSubstring("evaluating rules failed"))
+		})
+
+	})
+
+})
@@ -0,0 +1,230 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package promql
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+	"sync"
+
+	prommodel "github.com/prometheus/client_model/go"
+	"github.com/prometheus/prometheus/model/histogram"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/value"
+	"github.com/prometheus/prometheus/storage"
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/prometheus/prometheus/tsdb/chunks"
+	"github.com/prometheus/prometheus/util/annotations"
+	"github.com/thediveo/pyrotest"
+)
+
+// sampleStore is a simple in-memory storage of float samples, serving as a
+// [storage.Queryable] for evaluating PromQL expressions locally.
+type sampleStore struct {
+	mu     sync.Mutex
+	series map[string]*storeSeries // indexed by labels.Labels.String().
+}
+
+var _ storage.Queryable = (*sampleStore)(nil)
+
+// storeSeries is a single timeseries with its samples sorted by timestamp.
+type storeSeries struct {
+	labels  labels.Labels
+	samples []chunks.Sample
+}
+
+// newSampleStore returns a new, empty sample store.
+func newSampleStore() *sampleStore {
+	return &sampleStore{series: map[string]*storeSeries{}}
+}
+
+// add adds a float sample to the series with the passed labels, replacing any
+// existing sample with the same timestamp.
+func (s *sampleStore) add(lset labels.Labels, t int64, f float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := lset.String()
+	series, ok := s.series[key]
+	if !ok {
+		series = &storeSeries{labels: lset}
+		s.series[key] = series
+	}
+	idx, found := slices.BinarySearchFunc(series.samples, t, func(sample chunks.Sample, t int64) int {
+		return cmp.Compare(sample.T(), t)
+	})
+	if found {
+		series.samples[idx] = floatSample{t: t, f: f}
+		return
+	}
+	series.samples = slices.Insert(series.samples, idx, chunks.Sample(floatSample{t: t, f: f}))
+}
+
+// markStale adds staleness markers at the specified timestamp in milliseconds
+// to all previous series that aren't current anymore, as Prometheus does for
+// series disappearing from a scrape or rule evaluation. Both the previous and
+// current series are indexed by labels.Labels.String().
+func (s *sampleStore) markStale(previous, current map[string]labels.Labels, t int64) {
+	for key, lset := range previous {
+		if _, ok := current[key]; !ok {
+			s.add(lset, t, math.Float64frombits(value.StaleNaN))
+		}
+	}
+}
+
+// addFamilies adds the samples of the passed metric families at the specified
+// timestamp in milliseconds, ignoring any timestamps of the individual
+// metrics. Histograms and summaries are added in form of their classic
+// “_bucket”, “_sum”, and “_count” series, as well as quantile series.
+// addFamilies returns the added series, indexed by labels.Labels.String().
+func (s *sampleStore) addFamilies(families pyrotest.MetricsFamilies, t int64) map[string]labels.Labels {
+	added := map[string]labels.Labels{}
+	// without any metric matchers, Timeseries never fails.
+	timeseries, _ := pyrotest.Timeseries(families)
+	for _, ts := range timeseries {
+		add := func(suffix string, extraName, extraValue string, value float64) {
+			b := labels.NewBuilder(labels.FromMap(ts.Labels))
+			if extraName != "" {
+				b.Set(extraName, extraValue)
+			}
+			b.Set(labels.MetricName, ts.Name+suffix)
+			lset := b.Labels()
+			s.add(lset, t, value)
+			added[lset.String()] = lset
+		}
+		switch ts.Type {
+		case prommodel.MetricType_COUNTER, prommodel.MetricType_GAUGE, prommodel.MetricType_UNTYPED:
+			add("", "", "", ts.Value)
+		case prommodel.MetricType_SUMMARY:
+			for quantile, value := range ts.Quantiles {
+				add("", "quantile", pyrotest.FormatFloat(quantile), value)
+			}
+			add("_sum", "", "", ts.Sum)
+			add("_count", "", "", ts.Count)
+		case prommodel.MetricType_HISTOGRAM, prommodel.MetricType_GAUGE_HISTOGRAM:
+			for upperBound, count := range ts.Buckets {
+				add("_bucket", "le", pyrotest.FormatFloat(upperBound), count)
+			}
+			if _, ok := ts.Buckets[math.Inf(+1)]; len(ts.Buckets) != 0 && !ok {
+				add("_bucket", "le", "+Inf", ts.Count)
+			}
+			add("_sum", "", "", ts.Sum)
+			add("_count", "", "", ts.Count)
+		}
+	}
+	return added
+}
+
+// Querier returns a querier over the samples within the specified time range.
+func (s *sampleStore) Querier(mint, maxt int64) (storage.Querier, error) {
+	return &storeQuerier{store: s, mint: mint, maxt: maxt}, nil
+}
+
+// storeQuerier queries the series of a sampleStore within a time range.
+type storeQuerier struct {
+	store      *sampleStore
+	mint, maxt int64
+}
+
+var _ storage.Querier = (*storeQuerier)(nil)
+
+// selectSeries returns the series matching all passed matchers, with their
+// samples restricted to the querier's time range.
+func (q *storeQuerier) selectSeries(matchers ...*labels.Matcher) []storage.Series {
+	q.store.mu.Lock()
+	defer q.store.mu.Unlock()
+	var result []storage.Series
+nextSeries:
+	for _, series := range q.store.series {
+		for _, matcher := range matchers {
+			if !matcher.Matches(series.labels.Get(matcher.Name)) {
+				continue nextSeries
+			}
+		}
+		var samples []chunks.Sample
+		for _, sample := range series.samples {
+			if sample.T() >= q.mint && sample.T() <= q.maxt {
+				samples = append(samples, sample)
+			}
+		}
+		if len(samples) == 0 {
+			continue
+		}
+		result = append(result, storage.NewListSeries(series.labels, samples))
+	}
+	slices.SortFunc(result, func(a, b storage.Series) int {
+		return labels.Compare(a.Labels(), b.Labels())
+	})
+	return result
+}
+
+func (q *storeQuerier) Select(_ context.Context, _ bool, _ *storage.SelectHints, matchers ...*labels.Matcher) storage.SeriesSet {
+	return &seriesSet{series: q.selectSeries(matchers...), idx: -1}
+}
+
+func (q *storeQuerier) LabelValues(_ context.Context, name string, _ *storage.LabelHints, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	var values []string
+	for _, series := range q.selectSeries(matchers...) {
+		if value := series.Labels().Get(name); value != "" && !slices.Contains(values, value) {
+			values = append(values, value)
+		}
+	}
+	slices.Sort(values)
+	return values, nil, nil
+}
+
+func (q *storeQuerier) LabelNames(_ context.Context, _ *storage.LabelHints, matchers ...*labels.Matcher) ([]string, annotations.Annotations, error) {
+	var names []string
+	for _, series := range q.selectSeries(matchers...) {
+		series.Labels().Range(func(label labels.Label) {
+			if !slices.Contains(names, label.Name) {
+				names = append(names, label.Name)
+			}
+		})
+	}
+	slices.Sort(names)
+	return names, nil, nil
+}
+
+func (q *storeQuerier) Close() error { return nil }
+
+// seriesSet is a simple slice-based storage.SeriesSet.
+type seriesSet struct {
+	series []storage.Series
+	idx    int
+}
+
+func (s *seriesSet) Next() bool {
+	s.idx++
+	return s.idx < len(s.series)
+}
+
+func (s *seriesSet) At() storage.Series                { return s.series[s.idx] }
+func (s *seriesSet) Err() error                        { return nil }
+func (s *seriesSet) Warnings() annotations.Annotations { return nil }
+
+// floatSample is a float sample implementing chunks.Sample.
+type floatSample struct {
+	t int64
+	f float64
+}
+
+func (s floatSample) T() int64                      { return s.t }
+func (s floatSample) F() float64                    { return s.f }
+func (s floatSample) H() *histogram.Histogram       { return nil }
+func (s floatSample) FH() *histogram.FloatHistogram { return nil }
+func (s floatSample) Type() chunkenc.ValueType      { return chunkenc.ValFloat }
+func (s floatSample) Copy() chunks.Sample           { return s }
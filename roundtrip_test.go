@@ -58,10 +58,12 @@ var _ = Describe("roundtrip", func() {
 		Expect(families).To(ContainMetrics(
 			Gauge(HaveName("bar_baz"),
 				HaveHelp(Not(BeEmpty())),
-				HaveLabel("foobar=baz")),
+				HaveLabel("foobar=baz"),
+				HaveSampleValue(BeNumerically("~", 66.6))),
 			Counter(HaveName(ContainSubstring("_total")),
 				HaveHelp(ContainSubstring("no help")),
-				HaveLabelWithValue("label", "scam")),
+				HaveLabelWithValue("label", "scam"),
+				HaveSampleValue(42)),
 		))
 
 		Expect(CollectAndLint(c, "foo_total")).To(HaveLen(1))
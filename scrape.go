@@ -0,0 +1,179 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	prommodel "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+
+	gi "github.com/onsi/ginkgo/v2"
+	gom "github.com/onsi/gomega"
+	"github.com/onsi/gomega/types"
+)
+
+// scrapeAcceptHeader negotiates the exposition format in the order of
+// preference: delimited protobuf, OpenMetrics text, and finally the classic
+// text format.
+const scrapeAcceptHeader = `application/vnd.google.protobuf;proto=io.prometheus.client.MetricFamily;encoding=delimited;q=0.7,` +
+	`application/openmetrics-text;version=1.0.0;q=0.5,` +
+	`text/plain;version=0.0.4;q=0.3,` +
+	`*/*;q=0.1`
+
+// DefaultScrapeTimeout limits the time for scraping a metrics endpoint, unless
+// a different timeout has been set using [ScrapeWith].
+const DefaultScrapeTimeout = 30 * time.Second
+
+// maxBodyExcerpt is the maximum length of a response body excerpt when
+// reporting scrape problems.
+const maxBodyExcerpt = 512
+
+// ScrapeAndLint scrapes metrics from the HTTP metrics endpoint at the passed
+// URL, linting them, and finally returns them if there are neither errors nor
+// linting issues. Otherwise, ScrapeAndLint will fail the current test with
+// details about scraping or linting problems, such as the HTTP status, content
+// type, and an excerpt of the response body.
+//
+// ScrapeAndLint negotiates the exposition format with the endpoint, preferring
+// the delimited protobuf format, then the OpenMetrics text format, and finally
+// the classic text format.
+//
+// Scraping times out after [DefaultScrapeTimeout]; use [ScrapeWith] for a
+// different timeout.
+//
+// If any metric names are passed in, only metrics with those names are checked.
+func ScrapeAndLint(url string, metricNames ...string) MetricsFamilies {
+	gi.GinkgoHelper()
+	return scrapeAndLint(gom.Default, nil, 0, url, metricNames...)
+}
+
+func scrapeAndLint(gomega types.Gomega, lo *lintOptions, timeout time.Duration, url string, metricNames ...string) MetricsFamilies {
+	gi.GinkgoHelper()
+	THelper(gomega)()
+
+	metfams, err := scrape(url, timeout)
+	gomega.Expect(err).NotTo(gom.HaveOccurred(), "scraping metrics failed")
+
+	return lint(gomega, lo, metfams, metricNames...)
+}
+
+// scrape scrapes the metrics endpoint at the passed URL and returns the decoded
+// metric families, giving up after the specified timeout; a zero timeout
+// defaults to [DefaultScrapeTimeout]. In case of problems, the returned error
+// details the HTTP status, content type, and an excerpt of the response body.
+func scrape(url string, timeout time.Duration) ([]*prommodel.MetricFamily, error) {
+	if timeout <= 0 {
+		timeout = DefaultScrapeTimeout
+	}
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", scrapeAcceptHeader)
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if resp.StatusCode != http.StatusOK {
+		return nil, scrapeError(errors.New("unexpected HTTP status"), resp.Status, contentType, body)
+	}
+	metfams, err := decodeExposition(body, contentType)
+	if err != nil {
+		return nil, scrapeError(err, resp.Status, contentType, body)
+	}
+	return metfams, nil
+}
+
+// scrapeError returns an error detailing the HTTP status, content type, and an
+// excerpt of the response body.
+func scrapeError(err error, status string, contentType string, body []byte) error {
+	excerpt := string(body)
+	if len(body) > maxBodyExcerpt {
+		excerpt = strings.ToValidUTF8(string(body[:maxBodyExcerpt]), "") + "..."
+	}
+	return fmt.Errorf("%w\nHTTP status: %s\ncontent type: %q\nbody excerpt:\n%s",
+		err, status, contentType, excerpt)
+}
+
+// decodeExposition decodes the metric families in the passed exposition,
+// using the exposition format indicated by the passed content type. It returns
+// the decoded metric families sorted by name.
+func decodeExposition(body []byte, contentType string) ([]*prommodel.MetricFamily, error) {
+	mediatype, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, fmt.Errorf("invalid content type: %w", err)
+	}
+	var families map[string]*prommodel.MetricFamily
+	switch mediatype {
+	case expfmt.ProtoType:
+		if params["proto"] != expfmt.ProtoProtocol || params["encoding"] != "delimited" {
+			return nil, fmt.Errorf("unsupported protobuf content type %q", contentType)
+		}
+		return decodeProtoDelimited(body)
+	case expfmt.OpenMetricsType:
+		families, err = parseOpenMetrics(bytes.NewReader(body))
+	case "text/plain":
+		var parser expfmt.TextParser
+		families, err = parser.TextToMetricFamilies(bytes.NewReader(body))
+	default:
+		return nil, fmt.Errorf("unsupported content type %q", contentType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return slices.SortedFunc(func(yield func(*prommodel.MetricFamily) bool) {
+		for _, family := range families {
+			if !yield(family) {
+				return
+			}
+		}
+	}, func(a, b *prommodel.MetricFamily) int {
+		return strings.Compare(a.GetName(), b.GetName())
+	}), nil
+}
+
+// decodeProtoDelimited decodes metric families in the delimited protobuf
+// exposition format.
+func decodeProtoDelimited(body []byte) ([]*prommodel.MetricFamily, error) {
+	decoder := expfmt.NewDecoder(bytes.NewReader(body), expfmt.NewFormat(expfmt.TypeProtoDelim))
+	var metfams []*prommodel.MetricFamily
+	for {
+		metfam := &prommodel.MetricFamily{}
+		err := decoder.Decode(metfam)
+		if errors.Is(err, io.EOF) {
+			return metfams, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		metfams = append(metfams, metfam)
+	}
+}
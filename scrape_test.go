@@ -0,0 +1,166 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// staticHandler returns an HTTP handler always responding with the specified
+// status code, content type, and body.
+func staticHandler(status int, contentType string, body string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	})
+}
+
+var _ = Describe("scraping and linting metrics", func() {
+
+	It("scrapes using the protobuf format", func() {
+		reg := prometheus.NewPedanticRegistry()
+		c := prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "foo_total",
+			Help: "foo.",
+		}, []string{"code"})
+		c.WithLabelValues("200").Add(42)
+		Expect(reg.Register(c)).To(Succeed())
+
+		var accept string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			accept = r.Header.Get("Accept")
+			promhttp.HandlerFor(reg, promhttp.HandlerOpts{EnableOpenMetrics: true}).ServeHTTP(w, r)
+		}))
+		defer srv.Close()
+
+		Expect(ScrapeAndLint(srv.URL)).To(ConsistOfMetrics(
+			Counter(HaveName("foo_total"), HaveLabel("code=200"), HaveSampleValue(42))))
+		Expect(accept).To(HavePrefix("application/vnd.google.protobuf"))
+	})
+
+	It("scrapes using the OpenMetrics format", func() {
+		srv := httptest.NewServer(staticHandler(http.StatusOK,
+			"application/openmetrics-text; version=1.0.0; charset=utf-8",
+			"# TYPE foo counter\n# HELP foo foo.\nfoo_total 42\n# EOF\n"))
+		defer srv.Close()
+		Expect(ScrapeAndLint(srv.URL)).To(ConsistOfMetrics(
+			Counter(HaveName("foo_total"), HaveSampleValue(42))))
+	})
+
+	It("scrapes using the classic text format", func() {
+		srv := httptest.NewServer(staticHandler(http.StatusOK,
+			"text/plain; version=0.0.4; charset=utf-8",
+			"# HELP foo_total foo.\n# TYPE foo_total counter\nfoo_total 42\n# HELP bar bar.\n# TYPE bar gauge\nbar 1\n"))
+		defer srv.Close()
+		Expect(ScrapeAndLint(srv.URL)).To(ConsistOfMetrics(
+			Counter(HaveName("foo_total"), HaveSampleValue(42)),
+			Gauge(HaveName("bar"))))
+		Expect(ScrapeAndLint(srv.URL, "bar")).To(ConsistOfMetrics(
+			Gauge(HaveName("bar"))))
+	})
+
+	When("things fail", Serial, func() {
+
+		var g Gomega
+		var msg string
+
+		BeforeEach(func() {
+			msg = ""
+			g = NewGomega(func(message string, callerSkip ...int) { msg = message })
+		})
+
+		It("fails on an invalid URL", func() {
+			scrapeAndLint(g, nil, 0, "http://[::1")
+			Expect(msg).To(ContainSubstring("scraping metrics failed"))
+		})
+
+		It("times out on a stalling endpoint", func() {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				<-r.Context().Done()
+			}))
+			defer srv.Close()
+			start := time.Now()
+			For(g).ScrapeWith(100 * time.Millisecond).ScrapeAndLint(srv.URL)
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+			Expect(msg).To(ContainSubstring("Timeout exceeded"))
+		})
+
+		It("fails on an unexpected HTTP status", func() {
+			srv := httptest.NewServer(staticHandler(http.StatusNotFound, "text/plain", "nothing to see here"))
+			defer srv.Close()
+			scrapeAndLint(g, nil, 0, srv.URL)
+			Expect(msg).To(MatchRegexp(`unexpected HTTP status\n\s*HTTP status: 404 Not Found\n\s*content type: "text/plain"\n\s*body excerpt:\n\s*nothing to see here`))
+		})
+
+		It("fails on an unsupported content type", func() {
+			srv := httptest.NewServer(staticHandler(http.StatusOK, "application/json", `{"foo": 42}`))
+			defer srv.Close()
+			scrapeAndLint(g, nil, 0, srv.URL)
+			Expect(msg).To(ContainSubstring(`unsupported content type "application/json"`))
+		})
+
+		It("fails on a missing content type", func() {
+			srv := httptest.NewServer(staticHandler(http.StatusOK, "", "foo 42\n"))
+			defer srv.Close()
+			scrapeAndLint(g, nil, 0, srv.URL)
+			Expect(msg).To(ContainSubstring("invalid content type"))
+		})
+
+		It("fails on an unsupported protobuf encoding", func() {
+			srv := httptest.NewServer(staticHandler(http.StatusOK,
+				"application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=text", ""))
+			defer srv.Close()
+			scrapeAndLint(g, nil, 0, srv.URL)
+			Expect(msg).To(ContainSubstring("unsupported protobuf content type"))
+		})
+
+		It("fails on a broken protobuf exposition", func() {
+			srv := httptest.NewServer(staticHandler(http.StatusOK,
+				"application/vnd.google.protobuf; proto=io.prometheus.client.MetricFamily; encoding=delimited",
+				"\x42garbage"))
+			defer srv.Close()
+			scrapeAndLint(g, nil, 0, srv.URL)
+			Expect(msg).To(ContainSubstring("scraping metrics failed"))
+		})
+
+		It("fails with a truncated body excerpt on broken text expositions", func() {
+			srv := httptest.NewServer(staticHandler(http.StatusOK, "text/plain",
+				"foo{\n"+strings.Repeat("x", 2*maxBodyExcerpt)))
+			defer srv.Close()
+			scrapeAndLint(g, nil, 0, srv.URL)
+			Expect(msg).To(MatchRegexp(`text format parsing error in line 1(.|\n)*body excerpt:\n\s*foo\{\n\s*x+\.\.\.`))
+		})
+
+		It("fails given linting problems", func() {
+			srv := httptest.NewServer(staticHandler(http.StatusOK, "text/plain",
+				"# HELP foo foo.\n# TYPE foo counter\nfoo 42\n"))
+			defer srv.Close()
+			scrapeAndLint(g, nil, 0, srv.URL)
+			Expect(msg).To(ContainSubstring("counter metrics should have \\\"_total\\\" suffix"))
+		})
+
+	})
+
+})
@@ -0,0 +1,354 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"maps"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	prommodel "github.com/prometheus/client_model/go"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+)
+
+// UpdateSnapshotsEnv is the name of the environment variable that, when set to
+// a true value, such as “1” or “true”, switches [MatchMetricsSnapshot] into
+// update mode.
+const UpdateSnapshotsEnv = "PYROTEST_UPDATE_SNAPSHOTS"
+
+// updateSnapshots is set by the test binary flag that switches
+// [MatchMetricsSnapshot] into update mode, if registered using
+// [RegisterFlags].
+var updateSnapshots bool
+
+// RegisterFlags registers the “-pyrotest.update-snapshots” flag with the
+// passed flag set, switching [MatchMetricsSnapshot] into update mode. This
+// package doesn't register any flags on its own, so register them explicitly,
+// typically with the test binary's flag set:
+//
+//	func init() {
+//	    pyrotest.RegisterFlags(flag.CommandLine)
+//	}
+func RegisterFlags(flags *flag.FlagSet) {
+	flags.BoolVar(&updateSnapshots, "pyrotest.update-snapshots", false,
+		"update metrics snapshot golden files instead of comparing against them")
+}
+
+// snapshotUpdateMode returns true if golden files should be (re)written
+// instead of being compared against.
+func snapshotUpdateMode() bool {
+	if updateSnapshots {
+		return true
+	}
+	update, _ := strconv.ParseBool(os.Getenv(UpdateSnapshotsEnv))
+	return update
+}
+
+// SnapshotOption configures how [MatchMetricsSnapshot] renders metric families
+// into their canonical text representation.
+type SnapshotOption func(*snapshotOptions)
+
+type snapshotOptions struct {
+	ignoreValues     bool
+	ignoreTimestamps bool
+	ignoreHelp       bool
+	ignoreLabels     map[string]struct{}
+}
+
+// IgnoreValues renders snapshots without any sample values, including
+// histogram bucket counts and summary quantile values, as well as without
+// native histogram buckets.
+func IgnoreValues() SnapshotOption {
+	return func(o *snapshotOptions) { o.ignoreValues = true }
+}
+
+// IgnoreTimestamps renders snapshots without any sample timestamps.
+func IgnoreTimestamps() SnapshotOption {
+	return func(o *snapshotOptions) { o.ignoreTimestamps = true }
+}
+
+// IgnoreHelp renders snapshots without any metric family help texts.
+func IgnoreHelp() SnapshotOption {
+	return func(o *snapshotOptions) { o.ignoreHelp = true }
+}
+
+// IgnoreLabels renders snapshots without the labels of the specified names.
+func IgnoreLabels(names ...string) SnapshotOption {
+	return func(o *snapshotOptions) {
+		if o.ignoreLabels == nil {
+			o.ignoreLabels = map[string]struct{}{}
+		}
+		for _, name := range names {
+			o.ignoreLabels[name] = struct{}{}
+		}
+	}
+}
+
+// MetricsSnapshotMatcher is a [types.GomegaMatcher] that succeeds if the
+// canonical text representation of an actual [MetricsFamilies] map equals the
+// contents of a golden file.
+type MetricsSnapshotMatcher struct {
+	Path     string
+	options  snapshotOptions
+	expected string
+	actual   string
+}
+
+var (
+	_ types.GomegaMatcher   = (*MetricsSnapshotMatcher)(nil)
+	_ format.GomegaStringer = (*MetricsSnapshotMatcher)(nil)
+)
+
+// MatchMetricsSnapshot succeeds if actual is a [MetricsFamilies] map that
+// renders into the same canonical, sorted text exposition as stored in the
+// golden file at the specified path. Options allow to ignore volatile details,
+// such as values, timestamps, help texts, and selected labels.
+//
+// When either the “-pyrotest.update-snapshots” test flag (see [RegisterFlags])
+// has been specified or the [UpdateSnapshotsEnv] environment variable has been
+// set to a true value, MatchMetricsSnapshot (re)writes the golden file from the
+// actual metric families and always succeeds.
+func MatchMetricsSnapshot(path string, opts ...SnapshotOption) *MetricsSnapshotMatcher {
+	m := &MetricsSnapshotMatcher{Path: path}
+	for _, opt := range opts {
+		opt(&m.options)
+	}
+	return m
+}
+
+func (m *MetricsSnapshotMatcher) GomegaString() string {
+	if s := m.options.String(); s != "" {
+		return fmt.Sprintf("snapshot %q (%s)", m.Path, s)
+	}
+	return fmt.Sprintf("snapshot %q", m.Path)
+}
+
+func (m *MetricsSnapshotMatcher) Match(actual any) (bool, error) {
+	familiesMap, ok := asFamiliesMap(actual)
+	if !ok {
+		return false, fmt.Errorf(
+			"MatchMetricsSnapshot matcher expects a non-nil map of metric families, indexed by their names.  Got:\n%s",
+			format.Object(actual, 1))
+	}
+	m.actual = renderSnapshot(familiesMap, &m.options)
+	if snapshotUpdateMode() {
+		if err := os.MkdirAll(filepath.Dir(m.Path), 0o755); err != nil {
+			return false, fmt.Errorf("cannot update metrics snapshot: %w", err)
+		}
+		if err := os.WriteFile(m.Path, []byte(m.actual), 0o644); err != nil {
+			return false, fmt.Errorf("cannot update metrics snapshot: %w", err)
+		}
+		m.expected = m.actual
+		return true, nil
+	}
+	golden, err := os.ReadFile(m.Path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, fmt.Errorf("metrics snapshot %q does not exist; rerun with -pyrotest.update-snapshots or %s=1 to create it",
+				m.Path, UpdateSnapshotsEnv)
+		}
+		return false, fmt.Errorf("cannot read metrics snapshot: %w", err)
+	}
+	m.expected = string(golden)
+	return m.expected == m.actual, nil
+}
+
+func (m *MetricsSnapshotMatcher) FailureMessage(actual any) string {
+	return fmt.Sprintf("Expected metrics to match %s\n%s",
+		m.GomegaString(), unifiedDiff(m.Path, "actual", m.expected, m.actual))
+}
+
+func (m *MetricsSnapshotMatcher) NegatedFailureMessage(actual any) string {
+	return fmt.Sprintf("Expected metrics not to match %s", m.GomegaString())
+}
+
+// String returns a short description of the options in effect.
+func (o *snapshotOptions) String() string {
+	var ignored []string
+	if o.ignoreValues {
+		ignored = append(ignored, "values")
+	}
+	if o.ignoreTimestamps {
+		ignored = append(ignored, "timestamps")
+	}
+	if o.ignoreHelp {
+		ignored = append(ignored, "help")
+	}
+	if len(o.ignoreLabels) != 0 {
+		ignored = append(ignored, "labels "+strings.Join(slices.Sorted(maps.Keys(o.ignoreLabels)), ", "))
+	}
+	if len(ignored) == 0 {
+		return ""
+	}
+	return "ignoring " + strings.Join(ignored, ", ")
+}
+
+// renderSnapshot returns the canonical text representation of the passed
+// metric families. Metric families are sorted by name, and the metrics inside
+// a family by their labels. The representation follows the Prometheus text
+// exposition format, with the addition of “# UNIT” lines as well as
+// “# NATIVE” lines for native histograms.
+func renderSnapshot(families MetricsFamilies, o *snapshotOptions) string {
+	var s strings.Builder
+	for _, name := range slices.Sorted(maps.Keys(families)) {
+		family := families[name]
+		if family == nil {
+			continue
+		}
+		if help := family.GetHelp(); help != "" && !o.ignoreHelp {
+			fmt.Fprintf(&s, "# HELP %s %s\n", name, escapeHelp(help))
+		}
+		fmt.Fprintf(&s, "# TYPE %s %s\n", name, strings.ToLower(family.GetType().String()))
+		if unit := family.GetUnit(); unit != "" {
+			fmt.Fprintf(&s, "# UNIT %s %s\n", name, unit)
+		}
+		type rendered struct {
+			labels string
+			lines  string
+		}
+		metrics := make([]rendered, 0, len(family.GetMetric()))
+		for _, metric := range family.GetMetric() {
+			labels := snapshotLabels(metric.GetLabel(), o)
+			metrics = append(metrics, rendered{
+				labels: renderLabels(labels),
+				lines:  renderMetric(name, family.GetType(), metric, labels, o),
+			})
+		}
+		slices.SortStableFunc(metrics, func(a, b rendered) int {
+			return strings.Compare(a.labels, b.labels)
+		})
+		for _, metric := range metrics {
+			s.WriteString(metric.lines)
+		}
+	}
+	return s.String()
+}
+
+// snapshotLabels returns the labels sorted by name, without any ignored
+// labels.
+func snapshotLabels(labels []*prommodel.LabelPair, o *snapshotOptions) []*prommodel.LabelPair {
+	result := make([]*prommodel.LabelPair, 0, len(labels))
+	for _, label := range labels {
+		if _, ignored := o.ignoreLabels[label.GetName()]; ignored {
+			continue
+		}
+		result = append(result, label)
+	}
+	slices.SortFunc(result, func(a, b *prommodel.LabelPair) int {
+		return strings.Compare(a.GetName(), b.GetName())
+	})
+	return result
+}
+
+// renderMetric returns the sample lines of a single metric.
+func renderMetric(
+	name string,
+	typ prommodel.MetricType,
+	metric *prommodel.Metric,
+	labels []*prommodel.LabelPair,
+	o *snapshotOptions,
+) string {
+	var s strings.Builder
+	sample := func(suffix string, extraName, extraValue string, value float64) {
+		s.WriteString(name + suffix)
+		if extraName != "" {
+			s.WriteString(renderLabels(append(slices.Clone(labels),
+				&prommodel.LabelPair{Name: &extraName, Value: &extraValue})))
+		} else {
+			s.WriteString(renderLabels(labels))
+		}
+		if !o.ignoreValues {
+			s.WriteString(" " + FormatFloat(value))
+		}
+		if metric.TimestampMs != nil && !o.ignoreTimestamps {
+			s.WriteString(" " + strconv.FormatInt(metric.GetTimestampMs(), 10))
+		}
+		s.WriteRune('\n')
+	}
+	switch typ {
+	case prommodel.MetricType_COUNTER:
+		sample("", "", "", metric.GetCounter().GetValue())
+	case prommodel.MetricType_GAUGE:
+		sample("", "", "", metric.GetGauge().GetValue())
+	case prommodel.MetricType_UNTYPED:
+		sample("", "", "", metric.GetUntyped().GetValue())
+	case prommodel.MetricType_SUMMARY:
+		summary := metric.GetSummary()
+		for _, quantile := range summary.GetQuantile() {
+			sample("", "quantile", FormatFloat(quantile.GetQuantile()), quantile.GetValue())
+		}
+		sample("_sum", "", "", summary.GetSampleSum())
+		sample("_count", "", "", float64(summary.GetSampleCount()))
+	case prommodel.MetricType_HISTOGRAM, prommodel.MetricType_GAUGE_HISTOGRAM:
+		h := metric.GetHistogram()
+		buckets := h.GetBucket()
+		for _, bucket := range buckets {
+			sample("_bucket", "le", FormatFloat(bucket.GetUpperBound()), bucketCount(bucket))
+		}
+		// the protobuf exposition usually leaves the “+Inf” bucket implicit,
+		// whereas the text exposition always lists it, so we canonicalize.
+		if len(buckets) != 0 && !math.IsInf(buckets[len(buckets)-1].GetUpperBound(), +1) {
+			sample("_bucket", "le", "+Inf", histogramSampleCount(h))
+		}
+		sample("_sum", "", "", h.GetSampleSum())
+		sample("_count", "", "", histogramSampleCount(h))
+		if isNativeHistogram(h) {
+			fmt.Fprintf(&s, "# NATIVE %s%s", name, renderLabels(labels))
+			if o.ignoreValues {
+				fmt.Fprintf(&s, " schema: %d\n", h.GetSchema())
+			} else {
+				s.WriteString(" " + nativeHistogramLayout(h) + "\n")
+			}
+		}
+	}
+	return s.String()
+}
+
+// renderLabels returns the passed labels in text exposition notation, or an
+// empty string if there are no labels.
+func renderLabels(labels []*prommodel.LabelPair) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	var s strings.Builder
+	s.WriteRune('{')
+	for idx, label := range labels {
+		if idx > 0 {
+			s.WriteRune(',')
+		}
+		s.WriteString(label.GetName() + `="` + escapeLabelValue(label.GetValue()) + `"`)
+	}
+	s.WriteRune('}')
+	return s.String()
+}
+
+var (
+	helpEscaper       = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelValueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(help string) string { return helpEscaper.Replace(help) }
+
+func escapeLabelValue(value string) string { return labelValueEscaper.Replace(value) }
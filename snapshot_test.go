@@ -0,0 +1,193 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("metrics snapshots", func() {
+
+	var families MetricsFamilies
+
+	BeforeEach(func() {
+		reg := prometheus.NewPedanticRegistry()
+		c := prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "foo_total",
+			Help: "foo \\ counts\nthings.",
+		}, []string{"instance", "code"})
+		c.WithLabelValues("abc", "404").Add(1)
+		c.WithLabelValues("def", "200").Add(42)
+		h := prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "bar_seconds",
+			Help:    "bar.",
+			Buckets: []float64{0.1, 1},
+		})
+		h.Observe(0.5)
+		s := prometheus.NewSummary(prometheus.SummaryOpts{
+			Name:       "baz",
+			Help:       "baz.",
+			Objectives: map[float64]float64{0.5: 0.05},
+		})
+		s.Observe(2)
+		Expect(reg.Register(c)).To(Succeed())
+		Expect(reg.Register(h)).To(Succeed())
+		Expect(reg.Register(s)).To(Succeed())
+		families = GatherAndLint(reg)
+	})
+
+	It("renders canonical snapshots", func() {
+		Expect(renderSnapshot(families, &snapshotOptions{})).To(Equal(`# HELP bar_seconds bar.
+# TYPE bar_seconds histogram
+bar_seconds_bucket{le="0.1"} 0
+bar_seconds_bucket{le="1"} 1
+bar_seconds_bucket{le="+Inf"} 1
+bar_seconds_sum 0.5
+bar_seconds_count 1
+# HELP baz baz.
+# TYPE baz summary
+baz{quantile="0.5"} 2
+baz_sum 2
+baz_count 1
+# HELP foo_total foo \\ counts\nthings.
+# TYPE foo_total counter
+foo_total{code="200",instance="def"} 42
+foo_total{code="404",instance="abc"} 1
+`))
+	})
+
+	It("renders snapshots ignoring details", func() {
+		var o snapshotOptions
+		for _, opt := range []SnapshotOption{IgnoreValues(), IgnoreTimestamps(), IgnoreHelp(), IgnoreLabels("instance")} {
+			opt(&o)
+		}
+		Expect(o.String()).To(Equal("ignoring values, timestamps, help, labels instance"))
+		snapshot := renderSnapshot(MetricsFamilies{"foo_total": families["foo_total"]}, &o)
+		Expect(snapshot).To(Equal(`# TYPE foo_total counter
+foo_total{code="200"}
+foo_total{code="404"}
+`))
+	})
+
+	It("matches golden files", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "testdata", "metrics.snapshot")
+		Expect(MatchMetricsSnapshot(path).Match(families)).Error().To(
+			MatchError(ContainSubstring("does not exist")))
+
+		GinkgoT().Setenv(UpdateSnapshotsEnv, "1")
+		Expect(families).To(MatchMetricsSnapshot(path, IgnoreValues()))
+		GinkgoT().Setenv(UpdateSnapshotsEnv, "")
+
+		Expect(families).To(MatchMetricsSnapshot(path, IgnoreValues()))
+		families["foo_total"].Metric[0].Counter.Value = nil
+		Expect(families).To(MatchMetricsSnapshot(path, IgnoreValues()))
+		Expect(families).NotTo(MatchMetricsSnapshot(path))
+	})
+
+	It("updates golden files when flagged", Serial, func() {
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		RegisterFlags(fs)
+		DeferCleanup(func() { updateSnapshots = false })
+		Expect(snapshotUpdateMode()).To(BeFalse())
+		Expect(fs.Parse([]string{"-pyrotest.update-snapshots"})).To(Succeed())
+		Expect(snapshotUpdateMode()).To(BeTrue())
+
+		path := filepath.Join(GinkgoT().TempDir(), "metrics.snapshot")
+		Expect(families).To(MatchMetricsSnapshot(path))
+		Expect(path).To(BeARegularFile())
+	})
+
+	It("reports differences as a unified diff", func() {
+		path := filepath.Join(GinkgoT().TempDir(), "metrics.snapshot")
+		Expect(os.WriteFile(path, []byte(renderSnapshot(families, &snapshotOptions{})), 0o644)).To(Succeed())
+		families["baz"].Metric[0].Summary.SampleCount = nil
+		m := MatchMetricsSnapshot(path, IgnoreHelp())
+		Expect(m.Match(families)).To(BeFalse())
+		Expect(m.FailureMessage(families)).To(MatchRegexp(
+			`Expected metrics to match snapshot ".*" \(ignoring help\)
+--- .*metrics.snapshot
+\+\+\+ actual
+@@ -1,16 \+1,13 @@
+-# HELP bar_seconds bar.
+ # TYPE bar_seconds histogram
+(.*\n)*-baz_count 1
+(.*\n)*\+baz_count 0
+`))
+		Expect(m.NegatedFailureMessage(families)).To(HavePrefix("Expected metrics not to match"))
+	})
+
+	It("rejects invalid actual values", func() {
+		Expect(MatchMetricsSnapshot("foo").Match(nil)).Error().To(HaveOccurred())
+	})
+
+	It("diffs texts", func() {
+		Expect(unifiedDiff("a", "b", "foo\n", "foo\n")).To(BeEmpty())
+		Expect(unifiedDiff("a", "b", "", "foo\n")).To(Equal("--- a\n+++ b\n@@ -0,0 +1 @@\n+foo\n"))
+		Expect(unifiedDiff("a", "b",
+			"1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			"1\nx\n3\n4\n5\n6\n7\n8\n9\n10\n11\ny\n")).To(Equal(`--- a
++++ b
+@@ -1,5 +1,5 @@
+ 1
+-2
++x
+ 3
+ 4
+ 5
+@@ -9,4 +9,4 @@
+ 9
+ 10
+ 11
+-12
++y
+`))
+		Expect(unifiedDiff("a", "b", "1\n2\n3\n", "1\n3\n4\n")).To(Equal(`--- a
++++ b
+@@ -1,3 +1,3 @@
+ 1
+-2
+ 3
++4
+`))
+	})
+
+	It("diffs missing final newlines and differing line endings", func() {
+		Expect(unifiedDiff("a", "b", "1\n2\n", "1\n2")).To(Equal(`--- a
++++ b
+@@ -1,2 +1,2 @@
+ 1
+-2
++2
+\ No newline at end of file
+`))
+		Expect(unifiedDiff("a", "b", "1\n2\n", "1\r\n2\r\n")).To(Equal(`--- a
++++ b
+@@ -1,2 +1,2 @@
+-1
+-2
++1
++2
+\ line endings differ: a uses LF, b uses CRLF
+`))
+	})
+
+})
@@ -0,0 +1,155 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+	prommodel "github.com/prometheus/client_model/go"
+)
+
+// summaryLayout returns a concise textual representation of the quantiles of
+// a summary metric, including the sample count and sample sum.
+func summaryLayout(metric *prommodel.Metric) string {
+	summary := metric.GetSummary()
+	var s strings.Builder
+	s.WriteRune('[')
+	for idx, quantile := range summary.GetQuantile() {
+		if idx > 0 {
+			s.WriteString(", ")
+		}
+		fmt.Fprintf(&s, "quantile=%s: %s",
+			FormatFloat(quantile.GetQuantile()), FormatFloat(quantile.GetValue()))
+	}
+	fmt.Fprintf(&s, "] count: %d, sum: %s",
+		summary.GetSampleCount(), FormatFloat(summary.GetSampleSum()))
+	return s.String()
+}
+
+// ----
+
+// SummaryQuantileMatcher matches a specific quantile of a summary and
+// optionally its value.
+type SummaryQuantileMatcher struct {
+	quantile float64
+	matcher  types.GomegaMatcher
+	expected any // original expected quantile value for error reporting.
+}
+
+var (
+	_ (MetricPropertyMatcher)   = (*SummaryQuantileMatcher)(nil)
+	_ (individualMetricMatcher) = (*SummaryQuantileMatcher)(nil)
+	_ (mismatchReasoner)        = (*SummaryQuantileMatcher)(nil)
+	_ (format.GomegaStringer)   = (*SummaryQuantileMatcher)(nil)
+)
+
+func (m *SummaryQuantileMatcher) GomegaString() string {
+	if m.expected == nil {
+		return fmt.Sprintf("quantile {quantile=%s}", FormatFloat(m.quantile))
+	}
+	return fmt.Sprintf("quantile {quantile=%s}: %s",
+		FormatFloat(m.quantile), numberString(m.expected))
+}
+
+func (m *SummaryQuantileMatcher) yesimametricpropertymatcher() {}
+
+// matchMetric succeeds if the summary of the passed metric has the expected
+// quantile and, if specified, a matching quantile value.
+func (m *SummaryQuantileMatcher) matchMetric(mf *prommodel.MetricFamily, metric *prommodel.Metric) (bool, error) {
+	if m.expected != nil && m.matcher == nil {
+		return false, errors.New(format.Message(
+			m.expected, "to be either a number or GomegaMatcher"))
+	}
+	if mf.GetType() != prommodel.MetricType_SUMMARY {
+		return false, nil
+	}
+	for _, quantile := range metric.GetSummary().GetQuantile() {
+		if quantile.GetQuantile() != m.quantile {
+			continue
+		}
+		if m.matcher == nil {
+			return true, nil
+		}
+		success, err := m.matcher.Match(quantile.GetValue())
+		if err != nil {
+			return false, err
+		}
+		if success {
+			return true, nil
+		}
+		break
+	}
+	return false, nil
+}
+
+func (m *SummaryQuantileMatcher) mismatchReason(mf *prommodel.MetricFamily, metric *prommodel.Metric) string {
+	return summaryMismatchReason(mf, metric)
+}
+
+// ----
+
+// SummaryQuantilesMatcher matches the complete set of quantiles of a summary.
+type SummaryQuantilesMatcher struct {
+	quantiles []float64 // sorted expected quantiles.
+}
+
+var (
+	_ (MetricPropertyMatcher)   = (*SummaryQuantilesMatcher)(nil)
+	_ (individualMetricMatcher) = (*SummaryQuantilesMatcher)(nil)
+	_ (mismatchReasoner)        = (*SummaryQuantilesMatcher)(nil)
+	_ (format.GomegaStringer)   = (*SummaryQuantilesMatcher)(nil)
+)
+
+func (m *SummaryQuantilesMatcher) GomegaString() string {
+	quantiles := make([]string, 0, len(m.quantiles))
+	for _, quantile := range m.quantiles {
+		quantiles = append(quantiles, FormatFloat(quantile))
+	}
+	return fmt.Sprintf("quantiles: [%s]", strings.Join(quantiles, ", "))
+}
+
+func (m *SummaryQuantilesMatcher) yesimametricpropertymatcher() {}
+
+// matchMetric succeeds if the summary of the passed metric has exactly the
+// expected quantiles, regardless of their order.
+func (m *SummaryQuantilesMatcher) matchMetric(mf *prommodel.MetricFamily, metric *prommodel.Metric) (bool, error) {
+	if mf.GetType() != prommodel.MetricType_SUMMARY {
+		return false, nil
+	}
+	actualQuantiles := []float64{}
+	for _, quantile := range metric.GetSummary().GetQuantile() {
+		actualQuantiles = append(actualQuantiles, quantile.GetQuantile())
+	}
+	slices.Sort(actualQuantiles)
+	return slices.Equal(actualQuantiles, m.quantiles), nil
+}
+
+func (m *SummaryQuantilesMatcher) mismatchReason(mf *prommodel.MetricFamily, metric *prommodel.Metric) string {
+	return summaryMismatchReason(mf, metric)
+}
+
+// summaryMismatchReason returns the actual quantiles of the passed summary
+// metric as the reason for a quantile mismatch.
+func summaryMismatchReason(mf *prommodel.MetricFamily, metric *prommodel.Metric) string {
+	if mf.GetType() != prommodel.MetricType_SUMMARY {
+		return "no quantiles for " + mf.GetType().String()
+	}
+	return "got " + summaryLayout(metric)
+}
@@ -0,0 +1,138 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+	"github.com/prometheus/client_golang/prometheus"
+	prommodel "github.com/prometheus/client_model/go"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("summary property matchers", func() {
+
+	summaryFamily := &prommodel.MetricFamily{
+		Name: pstr("foo_seconds"),
+		Type: prommodel.MetricType_SUMMARY.Enum(),
+		Metric: []*prommodel.Metric{
+			{
+				Label: []*prommodel.LabelPair{{Name: pstr("code"), Value: pstr("200")}},
+				Summary: &prommodel.Summary{
+					SampleCount: puint64(4),
+					SampleSum:   pfloat(1.25),
+					Quantile: []*prommodel.Quantile{
+						{Quantile: pfloat(0.5), Value: pfloat(0.25)},
+						{Quantile: pfloat(0.9), Value: pfloat(0.5)},
+					},
+				},
+			},
+		},
+	}
+
+	DescribeTable("GomegaString",
+		func(m MetricPropertyMatcher, expected string) {
+			gs, ok := m.(format.GomegaStringer)
+			Expect(ok).To(BeTrue(), "not a GomegaStringer: %T", m)
+			Expect(gs.GomegaString()).To(MatchRegexp(expected))
+		},
+		Entry("quantile", HaveQuantile(0.5, 0.25), `^quantile \{quantile=0.5\}: 0.25$`),
+		Entry("quantile only", HaveQuantile(0.5, nil), `^quantile \{quantile=0.5\}$`),
+		Entry("quantiles", HaveQuantiles(0.9, 0.5), `^quantiles: \[0.5, 0.9\]$`),
+	)
+
+	It("rejects an invalid expected value", func() {
+		Expect(HaveQuantile(0.5, "42").(individualMetricMatcher).
+			matchMetric(summaryFamily, summaryFamily.Metric[0])).Error().To(MatchError(
+			ContainSubstring("to be either a number or GomegaMatcher")))
+	})
+
+	DescribeTable("matching summary properties",
+		func(m MetricPropertyMatcher, matchExpectations types.GomegaMatcher) {
+			Expect(m.(individualMetricMatcher).matchMetric(summaryFamily, summaryFamily.Metric[0])).To(matchExpectations)
+		},
+		Entry("sample count", HaveSampleCount(4), BeTrue()),
+		Entry("wrong sample count", HaveSampleCount(5), BeFalse()),
+		Entry("sample count as float", HaveSampleCount(Equal(4.0)), BeTrue()),
+		Entry("sample sum", HaveSampleSum(1.25), BeTrue()),
+		Entry("wrong sample sum", HaveSampleSum(1.0), BeFalse()),
+		Entry("quantile", HaveQuantile(0.5, 0.25), BeTrue()),
+		Entry("quantile within tolerance", HaveQuantile(0.9, BeNumerically("~", 0.45, 0.1)), BeTrue()),
+		Entry("existing quantile", HaveQuantile(0.9, nil), BeTrue()),
+		Entry("missing quantile", HaveQuantile(0.99, nil), BeFalse()),
+		Entry("wrong quantile value", HaveQuantile(0.5, 0.5), BeFalse()),
+		Entry("quantiles", HaveQuantiles(0.9, 0.5), BeTrue()),
+		Entry("wrong quantiles", HaveQuantiles(0.5), BeFalse()),
+		Entry("bucket", HaveBucket(0.5, 0), BeFalse()),
+	)
+
+	It("doesn't match other metric types", func() {
+		gaugeFamily := &prommodel.MetricFamily{
+			Name:   pstr("foo"),
+			Type:   prommodel.MetricType_GAUGE.Enum(),
+			Metric: []*prommodel.Metric{{Gauge: &prommodel.Gauge{Value: pfloat(0)}}},
+		}
+		Expect(HaveQuantile(0.5, nil).(individualMetricMatcher).matchMetric(gaugeFamily, gaugeFamily.Metric[0])).To(BeFalse())
+		Expect(HaveQuantiles().(individualMetricMatcher).matchMetric(gaugeFamily, gaugeFamily.Metric[0])).To(BeFalse())
+	})
+
+	It("reports the actual quantiles", func() {
+		m := BeAMetric(Summary(HaveName("foo_seconds"), HaveQuantile(0.5, 42)))
+		Expect(m.Match(summaryFamily)).To(BeFalse())
+		Expect(m.FailureMessage(summaryFamily)).To(MatchRegexp(
+			`metric \{code="200"\}:
+.*✗ quantile \{quantile=0.5\}: 42, got \[quantile=0.5: 0.25, quantile=0.9: 0.5\] count: 4, sum: 1.25`))
+
+		m = BeAMetric(Summary(HaveQuantiles(0.5, 0.99)))
+		Expect(m.Match(summaryFamily)).To(BeFalse())
+		Expect(m.FailureMessage(summaryFamily)).To(ContainSubstring(
+			"✗ quantiles: [0.5, 0.99], got [quantile=0.5: 0.25, quantile=0.9: 0.5] count: 4, sum: 1.25"))
+	})
+
+	It("doesn't report stale quantiles", func() {
+		m := HaveQuantile(0.5, 42)
+		Expect(Summary(m).match(summaryFamily)).To(BeFalse())
+		Expect(gomegaString(m)).To(Equal("quantile {quantile=0.5}: 42"))
+		Expect(m.(mismatchReasoner).mismatchReason(
+			&prommodel.MetricFamily{Type: prommodel.MetricType_GAUGE.Enum()}, nil)).To(
+			Equal("no quantiles for GAUGE"))
+	})
+
+	It("reports the actual sample count", func() {
+		Expect(HaveSampleCount(5).(mismatchReasoner).mismatchReason(
+			summaryFamily, summaryFamily.Metric[0])).To(Equal("got 4"))
+	})
+
+	It("reasons about collected summaries", func() {
+		s := prometheus.NewSummary(prometheus.SummaryOpts{
+			Name:       "foo_duration_seconds",
+			Help:       "foo duration.",
+			Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01},
+		})
+		for _, v := range []float64{0.1, 0.2, 0.3, 0.4, 0.5} {
+			s.Observe(v)
+		}
+		Expect(CollectAndLint(s)).To(ContainMetrics(
+			Summary(HaveName("foo_duration_seconds"),
+				HaveSampleCount(5),
+				HaveSampleSum(BeNumerically("~", 1.5)),
+				HaveQuantiles(0.5, 0.9),
+				HaveQuantile(0.5, BeNumerically("~", 0.3, 0.1))),
+		))
+	})
+
+})
@@ -0,0 +1,364 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	gom "github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+	"github.com/prometheus/client_golang/prometheus"
+	prommodel "github.com/prometheus/client_model/go"
+)
+
+// labelFilter selects individual metrics (timeseries) based on label matchers
+// and absent label matchers.
+type labelFilter struct {
+	labelMatchers  []metricLabelMatcher
+	absentMatchers []metricAbsentLabelMatcher
+}
+
+// newLabelFilter returns a new labelFilter for the passed label matchers, such
+// as [HaveLabel], [HaveLabelWithValue], and [NotHaveLabel]. It panics when
+// passed any other property matcher.
+func newLabelFilter(labels []MetricPropertyMatcher) labelFilter {
+	var f labelFilter
+	for _, propm := range labels {
+		switch matcher := propm.(type) {
+		case metricLabelMatcher:
+			f.labelMatchers = append(f.labelMatchers, matcher)
+		case metricAbsentLabelMatcher:
+			f.absentMatchers = append(f.absentMatchers, matcher)
+		default:
+			panic(fmt.Sprintf("unsupported label filter matcher of type %T", propm))
+		}
+	}
+	return f
+}
+
+// match returns true if the passed labels pass this filter.
+func (f *labelFilter) match(labels []*prommodel.LabelPair) (bool, error) {
+	success, err := matchAllLabels(labels, f.labelMatchers)
+	if err != nil || !success {
+		return false, err
+	}
+	return matchAllAbsentLabels(labels, f.absentMatchers)
+}
+
+// count returns the number of metrics of the passed metric family passing
+// this filter.
+func (f *labelFilter) count(mf *prommodel.MetricFamily) (int, error) {
+	count := 0
+	for _, metric := range mf.GetMetric() {
+		success, err := f.match(metric.GetLabel())
+		if err != nil {
+			return 0, err
+		}
+		if success {
+			count++
+		}
+	}
+	return count, nil
+}
+
+// String returns the label filter matchers, each on its own indented line.
+func (f *labelFilter) String() string {
+	var s strings.Builder
+	for _, label := range f.labelMatchers {
+		s.WriteRune('\n')
+		s.WriteString(format.IndentString(label.(format.GomegaStringer).GomegaString(), 1))
+	}
+	for _, label := range f.absentMatchers {
+		s.WriteRune('\n')
+		s.WriteString(format.IndentString(label.(format.GomegaStringer).GomegaString(), 1))
+	}
+	return s.String()
+}
+
+// ----
+
+// TimeseriesCountMatcher matches the number of individual metrics (timeseries)
+// of a metric family, optionally only counting those metrics with matching
+// labels.
+type TimeseriesCountMatcher struct {
+	matcher  types.GomegaMatcher
+	expected any // original expected value for error reporting.
+	filter   labelFilter
+}
+
+var (
+	_ (MetricPropertyMatcher) = (*TimeseriesCountMatcher)(nil)
+	_ (metricPropertyMatcher) = (*TimeseriesCountMatcher)(nil)
+	_ (mismatchReasoner)      = (*TimeseriesCountMatcher)(nil)
+	_ (format.GomegaStringer) = (*TimeseriesCountMatcher)(nil)
+)
+
+func (m *TimeseriesCountMatcher) GomegaString() string {
+	return fmt.Sprintf("timeseries count: %s%s", numberString(m.expected), m.filter.String())
+}
+
+func (m *TimeseriesCountMatcher) yesimametricpropertymatcher() {}
+
+func (m *TimeseriesCountMatcher) matchProperty(mf *prommodel.MetricFamily) (bool, error) {
+	if m.matcher == nil {
+		return false, errors.New(format.Message(
+			m.expected, "to be either a number or GomegaMatcher"))
+	}
+	count, err := m.filter.count(mf)
+	if err != nil {
+		return false, err
+	}
+	return m.matcher.Match(count)
+}
+
+func (m *TimeseriesCountMatcher) mismatchReason(mf *prommodel.MetricFamily, _ *prommodel.Metric) string {
+	count, err := m.filter.count(mf)
+	if err != nil {
+		return "error: " + err.Error()
+	}
+	return fmt.Sprintf("got %d", count)
+}
+
+// ----
+
+// HaveTotalTimeseriesMatcher is a [types.GomegaMatcher] that succeeds if an
+// actual value is assignable to a [MetricsFamilies] map and the total number of
+// individual metrics (timeseries) across all metric families matches.
+type HaveTotalTimeseriesMatcher struct {
+	Expected    any
+	matcher     types.GomegaMatcher
+	filter      labelFilter
+	actualCount int
+}
+
+var _ types.GomegaMatcher = (*HaveTotalTimeseriesMatcher)(nil)
+
+// HaveTotalTimeseries succeeds if actual represents a [MetricsFamilies] map
+// and the total number of individual metrics (timeseries) across all metric
+// families either equals the passed number or matches the passed
+// GomegaMatcher. If any label matchers, such as [HaveLabel] or
+// [NotHaveLabel], are passed, only those individual metrics with matching
+// labels are counted. Passing any other property matcher panics.
+func HaveTotalTimeseries(count any, labels ...MetricPropertyMatcher) types.GomegaMatcher {
+	return &HaveTotalTimeseriesMatcher{
+		Expected: count,
+		matcher:  asNumberMatcher(count),
+		filter:   newLabelFilter(labels),
+	}
+}
+
+func (m *HaveTotalTimeseriesMatcher) Match(actual any) (bool, error) {
+	familiesMap, ok := asFamiliesMap(actual)
+	if !ok {
+		return false, fmt.Errorf(
+			"HaveTotalTimeseries matcher expects a non-nil map of metric families, indexed by their names.  Got:\n%s",
+			format.Object(actual, 1))
+	}
+	if m.matcher == nil {
+		return false, errors.New(format.Message(
+			m.Expected, "to be either a number or GomegaMatcher"))
+	}
+	m.actualCount = 0
+	for _, family := range familiesMap {
+		count, err := m.filter.count(family)
+		if err != nil {
+			return false, err
+		}
+		m.actualCount += count
+	}
+	return m.matcher.Match(m.actualCount)
+}
+
+func (m *HaveTotalTimeseriesMatcher) FailureMessage(actual any) string {
+	return fmt.Sprintf("Expected total timeseries count%s\n%s\nto match\n%s",
+		m.filter.String(),
+		format.Object(m.actualCount, 1),
+		format.Object(m.Expected, 1))
+}
+
+func (m *HaveTotalTimeseriesMatcher) NegatedFailureMessage(actual any) string {
+	return fmt.Sprintf("Expected total timeseries count%s\n%s\nnot to match\n%s",
+		m.filter.String(),
+		format.Object(m.actualCount, 1),
+		format.Object(m.Expected, 1))
+}
+
+// ----
+
+// TimeseriesValue is an individual metric (timeseries) flattened together with
+// the properties of its metric family into a self-contained value, so that
+// regular Gomega matchers, such as HaveField, HaveLen, and ConsistOf, apply.
+type TimeseriesValue struct {
+	Name   string
+	Type   prommodel.MetricType
+	Help   string
+	Unit   string
+	Labels prometheus.Labels
+	// Value is the sample value of a counter, gauge, or untyped metric;
+	// otherwise, it is zero.
+	Value float64
+	// Count and Sum are the sample count and sum of a histogram or summary
+	// metric; otherwise, they are zero.
+	Count, Sum float64
+	// Buckets maps the upper bounds of the classic buckets of a histogram
+	// metric to their cumulative counts; otherwise, it is nil.
+	Buckets map[float64]float64
+	// Quantiles maps the quantiles of a summary metric to their values;
+	// otherwise, it is nil.
+	Quantiles map[float64]float64
+	// Timestamp is the explicit timestamp of the metric, if any; otherwise, it
+	// is the zero time.
+	Timestamp time.Time
+	// Metric is the original individual metric for advanced use cases, such
+	// as native histograms and exemplars.
+	Metric *prommodel.Metric
+}
+
+// String returns the timeseries in its usual “name{labels} value” textual
+// representation.
+func (ts TimeseriesValue) String() string {
+	labels := labelsString(ts.Metric.GetLabel())
+	switch {
+	case isHistogramType(ts.Type), ts.Type == prommodel.MetricType_SUMMARY:
+		return fmt.Sprintf("%s%s count: %s, sum: %s",
+			ts.Name, labels, FormatFloat(ts.Count), FormatFloat(ts.Sum))
+	}
+	return fmt.Sprintf("%s%s %s", ts.Name, labels, FormatFloat(ts.Value))
+}
+
+// Timeseries flattens the passed metric families into a slice of individual
+// metrics (timeseries), sorted by name and labels. If any metric matchers,
+// such as [Counter] and [Gauge], are passed, only those individual metrics
+// matching at least one of the matchers are returned. Please note that a
+// metric matcher then needs to match a single individual metric with its
+// labels and individual metric properties, while metric family properties,
+// such as [HaveTimeseriesCount], still apply to the complete metric family.
+// Timeseries returns the first error of the metric matchers, such as for an
+// invalid expected value, instead of silently selecting no timeseries.
+//
+//	Expect(Timeseries(CollectAndLint(coll),
+//	    Counter(HaveName("jobs_total"), HaveLabel("state=failed")))).
+//	    To(HaveLen(2))
+func Timeseries(families MetricsFamilies, matchers ...MetricMatcher) ([]TimeseriesValue, error) {
+	timeseries := []TimeseriesValue{}
+	for _, mf := range families {
+		if mf == nil {
+			continue
+		}
+		for _, metric := range mf.GetMetric() {
+			selected, err := selectMetric(mf, metric, matchers)
+			if err != nil {
+				return nil, err
+			}
+			if !selected {
+				continue
+			}
+			timeseries = append(timeseries, newTimeseriesValue(mf, metric))
+		}
+	}
+	slices.SortFunc(timeseries, func(a, b TimeseriesValue) int {
+		return cmp.Or(strings.Compare(a.Name, b.Name),
+			strings.Compare(labelsString(a.Metric.GetLabel()), labelsString(b.Metric.GetLabel())))
+	})
+	return timeseries, nil
+}
+
+// WithTimeseries succeeds if the individual metrics (timeseries) selected by
+// the passed metric matcher from an actual [MetricsFamilies] map satisfy the
+// passed matcher. Pass a nil selector to select all individual metrics. Errors
+// of the selector fail the match. See also [Timeseries].
+//
+//	Expect(families).To(WithTimeseries(
+//	    Counter(HaveName("jobs_total")),
+//	    ContainElement(HaveField("Labels", HaveKeyWithValue("state", "failed")))))
+func WithTimeseries(selector MetricMatcher, matcher types.GomegaMatcher) types.GomegaMatcher {
+	return gom.WithTransform(func(families MetricsFamilies) ([]TimeseriesValue, error) {
+		if selector == nil {
+			return Timeseries(families)
+		}
+		return Timeseries(families, selector)
+	}, matcher)
+}
+
+// selectMetric returns true if the passed individual metric of the specified
+// metric family matches any of the passed metric matchers, or if there are no
+// metric matchers at all. It returns the first error of a metric matcher.
+func selectMetric(mf *prommodel.MetricFamily, metric *prommodel.Metric, matchers []MetricMatcher) (bool, error) {
+	if len(matchers) == 0 {
+		return true, nil
+	}
+	for _, matcher := range matchers {
+		success, err := matcher.matchIndividual(mf, metric)
+		if err != nil {
+			return false, err
+		}
+		if success {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// newTimeseriesValue returns the passed individual metric of the specified
+// metric family as a self-contained timeseries value.
+func newTimeseriesValue(mf *prommodel.MetricFamily, metric *prommodel.Metric) TimeseriesValue {
+	ts := TimeseriesValue{
+		Name:   mf.GetName(),
+		Type:   mf.GetType(),
+		Help:   mf.GetHelp(),
+		Unit:   mf.GetUnit(),
+		Labels: prometheus.Labels{},
+		Metric: metric,
+	}
+	for _, label := range metric.GetLabel() {
+		ts.Labels[label.GetName()] = label.GetValue()
+	}
+	if metric.TimestampMs != nil {
+		ts.Timestamp = time.UnixMilli(metric.GetTimestampMs())
+	}
+	switch {
+	case mf.GetType() == prommodel.MetricType_COUNTER:
+		ts.Value = metric.GetCounter().GetValue()
+	case mf.GetType() == prommodel.MetricType_GAUGE:
+		ts.Value = metric.GetGauge().GetValue()
+	case mf.GetType() == prommodel.MetricType_UNTYPED:
+		ts.Value = metric.GetUntyped().GetValue()
+	case isHistogramType(mf.GetType()):
+		h := metric.GetHistogram()
+		ts.Count = histogramSampleCount(h)
+		ts.Sum = h.GetSampleSum()
+		if len(h.GetBucket()) != 0 {
+			ts.Buckets = map[float64]float64{}
+			for _, bucket := range h.GetBucket() {
+				ts.Buckets[bucket.GetUpperBound()] = bucketCount(bucket)
+			}
+		}
+	case mf.GetType() == prommodel.MetricType_SUMMARY:
+		s := metric.GetSummary()
+		ts.Count = float64(s.GetSampleCount())
+		ts.Sum = s.GetSampleSum()
+		ts.Quantiles = map[float64]float64{}
+		for _, q := range s.GetQuantile() {
+			ts.Quantiles[q.GetQuantile()] = q.GetValue()
+		}
+	}
+	return ts
+}
@@ -0,0 +1,236 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"time"
+
+	"github.com/onsi/gomega/format"
+	"github.com/prometheus/client_golang/prometheus"
+	prommodel "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("timeseries counting", func() {
+
+	famsmap := map[string]*prommodel.MetricFamily{
+		"bottled_boris": {
+			Type: prommodel.MetricType_COUNTER.Enum(),
+			Name: pstr("bottled_boris"),
+			Metric: []*prommodel.Metric{
+				{Label: []*prommodel.LabelPair{{Name: pstr("type"), Value: pstr("champagne")}}},
+				{Label: []*prommodel.LabelPair{{Name: pstr("type"), Value: pstr("schaumwein")}}},
+				{Label: []*prommodel.LabelPair{{Name: pstr("type"), Value: pstr("sekt")}}},
+			},
+		},
+		"angry_angie": {
+			Type: prommodel.MetricType_GAUGE.Enum(),
+			Name: pstr("angry_angie"),
+			Metric: []*prommodel.Metric{
+				{Label: []*prommodel.LabelPair{{Name: pstr("realm"), Value: pstr("east")}}},
+				{Label: []*prommodel.LabelPair{{Name: pstr("realm"), Value: pstr("west")}}},
+			},
+		},
+	}
+
+	It("rejects unsupported label filter matchers", func() {
+		Expect(func() { HaveTimeseriesCount(1, HaveName("foo")) }).To(PanicWith(
+			ContainSubstring("unsupported label filter matcher")))
+		Expect(func() { HaveTotalTimeseries(1, HaveHelp("foo")) }).To(PanicWith(
+			ContainSubstring("unsupported label filter matcher")))
+	})
+
+	Context("per metric family", func() {
+
+		It("has a useful GomegaString", func() {
+			Expect(HaveTimeseriesCount(3).(format.GomegaStringer).GomegaString()).To(
+				Equal("timeseries count: 3"))
+			Expect(HaveTimeseriesCount(1, HaveLabel("type=sekt"), NotHaveLabel("realm")).(format.GomegaStringer).GomegaString()).To(
+				MatchRegexp(`^timeseries count: 1\n.*label \{type=sekt\}\n.*no label \{realm\}$`))
+		})
+
+		It("counts timeseries", func() {
+			Expect(famsmap).To(ContainMetrics(
+				Counter(HaveName("bottled_boris"), HaveTimeseriesCount(3)),
+				Gauge(HaveTimeseriesCount(BeNumerically("<", 3)))))
+			Expect(famsmap).NotTo(ContainMetrics(
+				Counter(HaveName("bottled_boris"), HaveTimeseriesCount(2))))
+		})
+
+		It("counts only matching timeseries", func() {
+			Expect(famsmap).To(ContainMetrics(
+				Counter(HaveTimeseriesCount(2, HaveLabel(HaveSuffix("e")), NotHaveLabel("type=sekt")))))
+		})
+
+		It("reports errors", func() {
+			Expect(ContainMetrics(Counter(HaveTimeseriesCount("3"))).Match(famsmap)).Error().To(MatchError(
+				ContainSubstring("to be either a number or GomegaMatcher")))
+			Expect(ContainMetrics(Counter(HaveTimeseriesCount(3, HaveLabel(42)))).Match(famsmap)).Error().To(
+				HaveOccurred())
+		})
+
+	})
+
+	Context("across all metric families", func() {
+
+		It("rejects actual values if they're not families maps", func() {
+			Expect(HaveTotalTimeseries(0).Match(nil)).Error().To(MatchError(
+				ContainSubstring("HaveTotalTimeseries matcher expects a non-nil map of metric families")))
+		})
+
+		It("rejects invalid expected counts", func() {
+			Expect(HaveTotalTimeseries("0").Match(famsmap)).Error().To(MatchError(
+				ContainSubstring("to be either a number or GomegaMatcher")))
+			Expect(HaveTotalTimeseries(0, HaveLabel(42)).Match(famsmap)).Error().To(HaveOccurred())
+		})
+
+		It("counts all timeseries", func() {
+			Expect(famsmap).To(HaveTotalTimeseries(5))
+			Expect(famsmap).To(HaveTotalTimeseries(BeNumerically(">", 4)))
+			Expect(famsmap).NotTo(HaveTotalTimeseries(4))
+			Expect(famsmap).To(HaveTotalTimeseries(2, HaveLabel("realm")))
+			Expect(famsmap).To(HaveTotalTimeseries(4, NotHaveLabel("type=sekt")))
+		})
+
+		It("reports the actual total count", func() {
+			m := HaveTotalTimeseries(42, HaveLabel("realm"))
+			Expect(m.Match(famsmap)).To(BeFalse())
+			Expect(m.FailureMessage(famsmap)).To(MatchRegexp(
+				`Expected total timeseries count
+.*label \{realm\}
+.*<int>: 2
+to match
+.*<int>: 42`))
+			Expect(m.NegatedFailureMessage(famsmap)).To(ContainSubstring("not to match"))
+		})
+
+	})
+
+})
+
+var _ = Describe("flattening timeseries", func() {
+
+	var families MetricsFamilies
+
+	BeforeEach(func() {
+		reg := prometheus.NewPedanticRegistry()
+		jobs := prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "jobs_total",
+			Help: "Number of jobs.",
+		}, []string{"state"})
+		jobs.WithLabelValues("done").Add(42)
+		jobs.WithLabelValues("failed").Add(2)
+		durations := prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "job_duration_seconds",
+			Help:    "Job durations.",
+			Buckets: []float64{1, 10},
+		})
+		durations.Observe(0.5)
+		durations.Observe(5)
+		sizes := prometheus.NewSummary(prometheus.SummaryOpts{
+			Name:       "job_size_bytes",
+			Help:       "Job sizes.",
+			Objectives: map[float64]float64{0.5: 0.05},
+		})
+		sizes.Observe(100)
+		reg.MustRegister(jobs, durations, sizes)
+		families = GatherAndLint(reg)
+	})
+
+	It("flattens all timeseries", func() {
+		timeseries, err := Timeseries(families)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(timeseries).To(HaveLen(4))
+		Expect(timeseries).To(HaveEach(HaveField("Metric", Not(BeNil()))))
+		Expect(timeseries).To(HaveExactElements(
+			And(
+				HaveField("Name", "job_duration_seconds"),
+				HaveField("Type", prommodel.MetricType_HISTOGRAM),
+				HaveField("Help", "Job durations."),
+				HaveField("Count", 2.0),
+				HaveField("Sum", 5.5),
+				HaveField("Buckets", Equal(map[float64]float64{1: 1, 10: 2}))),
+			And(
+				HaveField("Name", "job_size_bytes"),
+				HaveField("Type", prommodel.MetricType_SUMMARY),
+				HaveField("Count", 1.0),
+				HaveField("Quantiles", HaveKeyWithValue(0.5, 100.0))),
+			And(
+				HaveField("Name", "jobs_total"),
+				HaveField("Labels", Equal(prometheus.Labels{"state": "done"})),
+				HaveField("Value", 42.0),
+				HaveField("Timestamp", BeZero())),
+			And(
+				HaveField("Name", "jobs_total"),
+				HaveField("Labels", Equal(prometheus.Labels{"state": "failed"})),
+				HaveField("Value", 2.0)),
+		))
+		Expect(timeseries[2].String()).To(Equal(`jobs_total{state="done"} 42`))
+		Expect(timeseries[0].String()).To(Equal(`job_duration_seconds{} count: 2, sum: 5.5`))
+	})
+
+	It("selects timeseries", func() {
+		Expect(Timeseries(families,
+			Counter(HaveName("jobs_total"), HaveLabel("state=failed")),
+			Histogram())).To(ConsistOf(
+			HaveField("Name", "jobs_total"),
+			HaveField("Name", "job_duration_seconds")))
+		Expect(Timeseries(families, Gauge())).To(BeEmpty())
+	})
+
+	It("reports invalid selectors", func() {
+		Expect(Timeseries(families, Counter(HaveName(42)))).Error().To(HaveOccurred())
+		Expect(Timeseries(families, Counter(HaveSampleValue("42")))).Error().To(MatchError(
+			ContainSubstring("to be either a number or GomegaMatcher")))
+		Expect(Timeseries(families, Counter(HaveLabel(42)))).Error().To(HaveOccurred())
+		Expect(WithTimeseries(Counter(HaveSampleValue("42")), BeEmpty()).Match(families)).Error().To(MatchError(
+			ContainSubstring("to be either a number or GomegaMatcher")))
+	})
+
+	It("applies metric family properties to the complete family", func() {
+		Expect(Timeseries(families, Counter(HaveTimeseriesCount(2)))).To(HaveLen(2))
+		Expect(Timeseries(families, Counter(HaveTimeseriesCount(1)))).To(BeEmpty())
+		Expect(Timeseries(families,
+			Counter(HaveTimeseriesCount(2), HaveLabel("state=done")))).To(ConsistOf(
+			HaveField("Value", 42.0)))
+		Expect(Timeseries(families,
+			Counter(HaveTimeseriesCount(1, HaveLabel("state=failed")), HaveSampleValue(42)))).To(ConsistOf(
+			HaveField("Labels", HaveKeyWithValue("state", "done"))))
+	})
+
+	It("transforms metric families", func() {
+		Expect(families).To(WithTimeseries(
+			Counter(HaveName("jobs_total"), HaveSampleValue(BeNumerically(">", 1))),
+			HaveLen(2)))
+		Expect(families).To(WithTimeseries(nil, HaveLen(4)))
+		Expect(families).NotTo(WithTimeseries(
+			Counter(HaveLabel("state=failed")),
+			ContainElement(HaveField("Value", 42.0))))
+	})
+
+	It("returns explicit timestamps", func() {
+		ts := time.UnixMilli(1712345678500)
+		Expect(Timeseries(MetricsFamilies{"foo": {
+			Name:   pstr("foo"),
+			Type:   prommodel.MetricType_GAUGE.Enum(),
+			Metric: []*prommodel.Metric{{TimestampMs: proto.Int64(ts.UnixMilli())}},
+		}, "nil": nil})).To(ConsistOf(
+			HaveField("Timestamp", BeTemporally("==", ts))))
+	})
+
+})
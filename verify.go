@@ -0,0 +1,448 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	prommodel "github.com/prometheus/client_model/go"
+
+	gi "github.com/onsi/ginkgo/v2"
+	gom "github.com/onsi/gomega"
+	"github.com/onsi/gomega/format"
+	"github.com/onsi/gomega/types"
+)
+
+// collectRounds is the number of times a collector gets collected in order to
+// check for stable help texts and metric types.
+const collectRounds = 2
+
+// DefaultVerifyTimeout limits the time a collector's Describe or Collect may
+// take when verifying the collector, unless a different timeout has been set
+// using [VerifyTimeout].
+const DefaultVerifyTimeout = 10 * time.Second
+
+// maxVariableLabels is the maximum number of variable labels of a descriptor
+// that [inspectDesc] probes for.
+const maxVariableLabels = 64
+
+// VerifyOption configures the verification of collectors; pass verify options
+// to [VerifyCollector] or [Tester.VerifyCollector].
+type VerifyOption func(*verifyOptions)
+
+// verifyOptions configures the verification of collectors.
+type verifyOptions struct {
+	timeout          time.Duration
+	warningsAsErrors bool
+}
+
+// VerifyTimeout limits the time a collector's Describe or Collect may take to
+// the specified timeout, instead of [DefaultVerifyTimeout].
+func VerifyTimeout(timeout time.Duration) VerifyOption {
+	return func(o *verifyOptions) {
+		o.timeout = timeout
+	}
+}
+
+// TreatWarningsAsErrors fails the collector verification not only on
+// inconsistencies, but also on warnings about unchecked collectors and
+// described but never collected metrics.
+func TreatWarningsAsErrors() VerifyOption {
+	return func(o *verifyOptions) {
+		o.warningsAsErrors = true
+	}
+}
+
+// newVerifyOptions returns the verify options resulting from applying the
+// passed options to the defaults.
+func newVerifyOptions(opts ...VerifyOption) verifyOptions {
+	o := verifyOptions{timeout: DefaultVerifyTimeout}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// CollectorReport describes the inconsistencies found between what a collector
+// describes and what it actually collects. Unchecked collectors and described
+// but never collected metrics are only warnings, as they are perfectly legal,
+// such as in case of vectors without any children.
+type CollectorReport struct {
+	// Unchecked is true if the collector didn't describe any metrics
+	// (warning).
+	Unchecked bool
+	// Uncollected lists the descriptors that were described but never
+	// collected (warning).
+	Uncollected []string
+	// Undescribed lists the descriptors that were collected but never
+	// described.
+	Undescribed []string
+	// UnstableHelp lists the metric families with changing help texts.
+	UnstableHelp []string
+	// UnstableTypes lists the metric families with changing metric types.
+	UnstableTypes []string
+	// Errors lists the errors encountered while describing and collecting
+	// metrics.
+	Errors []string
+}
+
+var _ format.GomegaStringer = (*CollectorReport)(nil)
+
+// IsConsistent returns true if the report doesn't list any inconsistencies,
+// ignoring any warnings.
+func (r CollectorReport) IsConsistent() bool {
+	return len(r.Undescribed) == 0 &&
+		len(r.UnstableHelp) == 0 && len(r.UnstableTypes) == 0 &&
+		len(r.Errors) == 0
+}
+
+// HasWarnings returns true if the report warns about an unchecked collector
+// or described but never collected metrics.
+func (r CollectorReport) HasWarnings() bool {
+	return r.Unchecked || len(r.Uncollected) != 0
+}
+
+// GomegaString returns the inconsistencies and warnings in a structured
+// textual form.
+func (r CollectorReport) GomegaString() string {
+	var s strings.Builder
+	if r.Unchecked {
+		s.WriteString("warning: unchecked collector: Describe sent no descriptors\n")
+	}
+	section := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		s.WriteString(title + ":\n")
+		for _, item := range items {
+			s.WriteString(format.Indent + item + "\n")
+		}
+	}
+	section("warning: described but never collected", r.Uncollected)
+	section("collected but not described", r.Undescribed)
+	section("unstable help texts", r.UnstableHelp)
+	section("unstable metric types", r.UnstableTypes)
+	section("errors", r.Errors)
+	return strings.TrimSuffix(s.String(), "\n")
+}
+
+// VerifyCollector checks the passed [prometheus.Collector] for consistency
+// between the descriptors it describes and the metrics it actually collects.
+// VerifyCollector runs Describe and Collect separately, comparing the
+// descriptors by their fully-qualified names, constant labels, and variable
+// label names. It additionally collects multiple times in order to check for
+// stable help texts and metric types. If there are any inconsistencies,
+// VerifyCollector fails the current test with a structured report. Describe
+// and Collect panicking or taking longer than [DefaultVerifyTimeout] are
+// reported as errors.
+//
+// VerifyCollector also warns about unchecked collectors as well as described
+// metrics that were never collected, such as vectors without any children, but
+// doesn't fail on these warnings unless passed [TreatWarningsAsErrors].
+func VerifyCollector(coll prometheus.Collector, opts ...VerifyOption) CollectorReport {
+	gi.GinkgoHelper()
+	return verifyCollector(gom.Default, coll, opts...)
+}
+
+func verifyCollector(gomega types.Gomega, coll prometheus.Collector, opts ...VerifyOption) CollectorReport {
+	gi.GinkgoHelper()
+	THelper(gomega)()
+	o := newVerifyOptions(opts...)
+	report := collectorReport(coll, o.timeout)
+	gomega.Expect(report).To(beConsistent(o.warningsAsErrors))
+	return report
+}
+
+// beConsistentMatcher is a [types.GomegaMatcher] that succeeds if the actual
+// [CollectorReport] doesn't list any inconsistencies and optionally also no
+// warnings.
+type beConsistentMatcher struct {
+	warningsAsErrors bool
+}
+
+var _ types.GomegaMatcher = (*beConsistentMatcher)(nil)
+
+// beConsistent succeeds if actual is a [CollectorReport] without any
+// inconsistencies; if warningsAsErrors is true, the report additionally must
+// not contain any warnings.
+func beConsistent(warningsAsErrors bool) types.GomegaMatcher {
+	return &beConsistentMatcher{warningsAsErrors: warningsAsErrors}
+}
+
+func (m *beConsistentMatcher) Match(actual any) (bool, error) {
+	report, ok := actual.(CollectorReport)
+	if !ok {
+		return false, fmt.Errorf("beConsistent matcher expects a CollectorReport.  Got:\n%s",
+			format.Object(actual, 1))
+	}
+	return report.IsConsistent() && !(m.warningsAsErrors && report.HasWarnings()), nil
+}
+
+func (m *beConsistentMatcher) FailureMessage(actual any) string {
+	return fmt.Sprintf("Expected collector to be consistent, but verification reported\n%s",
+		format.IndentString(actual.(CollectorReport).GomegaString(), 1))
+}
+
+func (m *beConsistentMatcher) NegatedFailureMessage(actual any) string {
+	return "Expected collector not to be consistent"
+}
+
+// descIdentity describes a prometheus.Desc, with its identity being formed by
+// its fully-qualified name, constant labels, and variable label names, but not
+// the help text.
+type descIdentity struct {
+	fqName         string
+	help           string
+	constLabels    string
+	variableLabels string
+}
+
+// identity returns the textual identity of a descriptor without its help text.
+func (d descIdentity) identity() string {
+	return fmt.Sprintf("%s{constLabels: {%s}, variableLabels: {%s}}",
+		d.fqName, d.constLabels, d.variableLabels)
+}
+
+// inspectDesc returns the identity of the passed [prometheus.Desc], as the
+// descriptor fields are otherwise inaccessible. Instead of relying on the
+// undocumented textual representation of descriptors, inspectDesc creates
+// constant metrics for the descriptor: first, it finds the number of variable
+// labels by trying increasing numbers of label values. It then gathers such a
+// metric using a pedantic registry in order to learn the fully-qualified name
+// and help text. Finally, it creates another metric with different label
+// values in order to tell variable labels from constant labels.
+//
+// Variable label names are always sorted, as the order of variable labels
+// isn't relevant to a descriptor's identity.
+func inspectDesc(desc *prometheus.Desc) (descIdentity, error) {
+	var values []string
+	metric, err := prometheus.NewConstMetric(desc, prometheus.UntypedValue, 0)
+	for err != nil && len(values) < maxVariableLabels {
+		values = append(values, "a")
+		metric, err = prometheus.NewConstMetric(desc, prometheus.UntypedValue, 0, values...)
+	}
+	if err != nil {
+		// report the original problem, not a label cardinality mismatch.
+		_, err = prometheus.NewConstMetric(desc, prometheus.UntypedValue, 0)
+		return descIdentity{}, fmt.Errorf("invalid descriptor %s: %w", desc, err)
+	}
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(&singleMetricCollector{metric: metric}); err != nil {
+		return descIdentity{}, fmt.Errorf("invalid descriptor %s: %w", desc, err)
+	}
+	families, err := reg.Gather()
+	if err != nil {
+		return descIdentity{}, fmt.Errorf("invalid descriptor %s: %w", desc, err)
+	}
+	family := families[0]
+
+	for idx := range values {
+		values[idx] = "b"
+	}
+	other, err := prometheus.NewConstMetric(desc, prometheus.UntypedValue, 0, values...)
+	if err != nil {
+		return descIdentity{}, fmt.Errorf("invalid descriptor %s: %w", desc, err)
+	}
+	var otherMetric prommodel.Metric
+	if err := other.Write(&otherMetric); err != nil {
+		return descIdentity{}, fmt.Errorf("invalid descriptor %s: %w", desc, err)
+	}
+
+	var constLabels, variableLabels []string
+	otherLabels := otherMetric.GetLabel()
+	for idx, label := range family.GetMetric()[0].GetLabel() {
+		if label.GetValue() != otherLabels[idx].GetValue() {
+			variableLabels = append(variableLabels, label.GetName())
+			continue
+		}
+		constLabels = append(constLabels, fmt.Sprintf("%s=%q", label.GetName(), label.GetValue()))
+	}
+	return descIdentity{
+		fqName:         family.GetName(),
+		help:           family.GetHelp(),
+		constLabels:    strings.Join(constLabels, ","),
+		variableLabels: strings.Join(variableLabels, ","),
+	}, nil
+}
+
+// singleMetricCollector describes and collects only a single metric.
+type singleMetricCollector struct {
+	metric prometheus.Metric
+}
+
+func (c *singleMetricCollector) Describe(ch chan<- *prometheus.Desc) { ch <- c.metric.Desc() }
+
+func (c *singleMetricCollector) Collect(ch chan<- prometheus.Metric) { ch <- c.metric }
+
+// collectorReport runs Describe and multiple rounds of Collect on the passed
+// collector and reports any inconsistencies found. Describe and each Collect
+// round are given up after the specified timeout.
+func collectorReport(coll prometheus.Collector, timeout time.Duration) CollectorReport {
+	var report CollectorReport
+
+	identities := map[*prometheus.Desc]descIdentity{}
+	inspect := func(desc *prometheus.Desc) (descIdentity, bool) {
+		if d, ok := identities[desc]; ok {
+			return d, true
+		}
+		d, err := inspectDesc(desc)
+		if err != nil {
+			report.Errors = append(report.Errors, err.Error())
+			return descIdentity{}, false
+		}
+		identities[desc] = d
+		return d, true
+	}
+
+	described := map[string]descIdentity{}
+	descs, err := drain("Describe", timeout, coll.Describe)
+	if err != nil {
+		report.Errors = append(report.Errors, err.Error())
+	}
+	for _, desc := range descs {
+		if d, ok := inspect(desc); ok {
+			described[d.identity()] = d
+		}
+	}
+	report.Unchecked = err == nil && len(described) == 0
+	compare := err == nil && len(described) != 0
+
+	collected := map[string]struct{}{}
+	helps := map[string][]string{}
+	typs := map[string][]string{}
+	for range collectRounds {
+		metrics, err := drain("Collect", timeout, coll.Collect)
+		if err != nil {
+			report.Errors = append(report.Errors, err.Error())
+			compare = false
+		}
+		for _, metric := range metrics {
+			d, ok := inspect(metric.Desc())
+			if !ok {
+				continue
+			}
+			var m prommodel.Metric
+			if err := metric.Write(&m); err != nil {
+				report.Errors = append(report.Errors, fmt.Sprintf("%s: %s", d.fqName, err.Error()))
+				continue
+			}
+			collected[d.identity()] = struct{}{}
+			if !slices.Contains(helps[d.fqName], d.help) {
+				helps[d.fqName] = append(helps[d.fqName], d.help)
+			}
+			if typ := metricTypeName(&m); !slices.Contains(typs[d.fqName], typ) {
+				typs[d.fqName] = append(typs[d.fqName], typ)
+			}
+		}
+	}
+
+	if compare {
+		for identity := range described {
+			if _, ok := collected[identity]; !ok {
+				report.Uncollected = append(report.Uncollected, identity)
+			}
+		}
+		for identity := range collected {
+			if _, ok := described[identity]; !ok {
+				report.Undescribed = append(report.Undescribed, identity)
+			}
+		}
+	}
+	for fqName, help := range helps {
+		if len(help) > 1 {
+			report.UnstableHelp = append(report.UnstableHelp,
+				fmt.Sprintf("%s: %s", fqName, quotedList(help)))
+		}
+	}
+	for fqName, typ := range typs {
+		if len(typ) > 1 {
+			report.UnstableTypes = append(report.UnstableTypes,
+				fmt.Sprintf("%s: %s", fqName, strings.Join(typ, ", ")))
+		}
+	}
+	slices.Sort(report.Uncollected)
+	slices.Sort(report.Undescribed)
+	slices.Sort(report.UnstableHelp)
+	slices.Sort(report.UnstableTypes)
+	return report
+}
+
+// drain runs the passed Describe or Collect function of a collector, returning
+// all elements it sends. If the function panics or doesn't return within the
+// specified timeout, drain returns the elements received so far together with
+// an error. After a timeout, the still running function gets drained in the
+// background so that it doesn't block forever.
+func drain[T any](what string, timeout time.Duration, fn func(chan<- T)) ([]T, error) {
+	ch := make(chan T)
+	var panicked any
+	go func() {
+		defer close(ch)
+		defer func() { panicked = recover() }()
+		fn(ch)
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var elements []T
+	for {
+		select {
+		case element, ok := <-ch:
+			if !ok {
+				if panicked != nil {
+					return elements, fmt.Errorf("%s panicked: %v", what, panicked)
+				}
+				return elements, nil
+			}
+			elements = append(elements, element)
+		case <-timer.C:
+			go func() {
+				for range ch {
+				}
+			}()
+			return elements, fmt.Errorf("%s timed out after %s", what, timeout)
+		}
+	}
+}
+
+// metricTypeName returns the name of the type of the passed metric, based on
+// which of its value fields is set.
+func metricTypeName(m *prommodel.Metric) string {
+	switch {
+	case m.Counter != nil:
+		return "counter"
+	case m.Gauge != nil:
+		return "gauge"
+	case m.Summary != nil:
+		return "summary"
+	case m.Histogram != nil:
+		return "histogram"
+	case m.Untyped != nil:
+		return "untyped"
+	}
+	return "unknown"
+}
+
+// quotedList returns the passed strings quoted and comma-separated.
+func quotedList(ss []string) string {
+	quoted := make([]string, 0, len(ss))
+	for _, s := range ss {
+		quoted = append(quoted, strconv.Quote(s))
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,252 @@
+// Copyright 2025 Harald Albrecht.
+//
+// Licensed under the Apache License, Version 2.0 (the "License"); you may not
+// use this file except in compliance with the License. You may obtain a copy
+// of the License at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS, WITHOUT
+// WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied. See the
+// License for the specific language governing permissions and limitations
+// under the License.
+
+package pyrotest
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	prommodel "github.com/prometheus/client_model/go"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+)
+
+// funcCollector is a collector with pluggable Describe and Collect functions,
+// counting its Collect calls.
+type funcCollector struct {
+	describe func(ch chan<- *prometheus.Desc)
+	collect  func(round int, ch chan<- prometheus.Metric)
+	rounds   int
+}
+
+func (c *funcCollector) Describe(ch chan<- *prometheus.Desc) {
+	if c.describe != nil {
+		c.describe(ch)
+	}
+}
+
+func (c *funcCollector) Collect(ch chan<- prometheus.Metric) {
+	c.collect(c.rounds, ch)
+	c.rounds++
+}
+
+// stallingCollector is a collector that stalls after collecting a single
+// metric until its stall channel gets closed.
+type stallingCollector struct {
+	desc  *prometheus.Desc
+	stall chan struct{}
+}
+
+func (c *stallingCollector) Describe(ch chan<- *prometheus.Desc) { ch <- c.desc }
+
+func (c *stallingCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, 1)
+	<-c.stall
+}
+
+var _ = Describe("verifying collectors", func() {
+
+	It("inspects descriptors", func() {
+		d, err := inspectDesc(prometheus.NewDesc("foo_total", `help "me", {please}`,
+			[]string{"method", "code"}, prometheus.Labels{"a": `x",y={}`, "b": "z"}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d).To(Equal(descIdentity{
+			fqName:         "foo_total",
+			help:           `help "me", {please}`,
+			constLabels:    `a="x\",y={}",b="z"`,
+			variableLabels: "code,method",
+		}))
+		Expect(d.identity()).To(Equal(`foo_total{constLabels: {a="x\",y={}",b="z"}, variableLabels: {code,method}}`))
+
+		d, err = inspectDesc(prometheus.NewDesc("foo", "", nil, nil))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.identity()).To(Equal(`foo{constLabels: {}, variableLabels: {}}`))
+
+		d, err = inspectDesc(prometheus.NewDesc("foo", "foo.", nil, prometheus.Labels{"a": "a", "b": "b"}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.identity()).To(Equal(`foo{constLabels: {a="a",b="b"}, variableLabels: {}}`))
+	})
+
+	It("inspects descriptors with constrained labels", func() {
+		d, err := inspectDesc(prometheus.V2.NewDesc("foo", "foo.",
+			prometheus.ConstrainedLabels{{Name: "code", Constraint: strings.ToUpper}},
+			prometheus.Labels{"instance": "a"}))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(d.identity()).To(Equal(`foo{constLabels: {instance="a"}, variableLabels: {code}}`))
+	})
+
+	It("rejects invalid descriptors", func() {
+		_, err := inspectDesc(prometheus.NewDesc("foo", "foo.", []string{"code", "code"}, nil))
+		Expect(err).To(MatchError(ContainSubstring("invalid descriptor")))
+		Expect(err).To(MatchError(ContainSubstring(`duplicate label names`)))
+	})
+
+	It("accepts consistent collectors", func() {
+		c := prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "foo_total",
+			Help:        "foo.",
+			ConstLabels: prometheus.Labels{"instance": "a"},
+		}, []string{"code"})
+		c.WithLabelValues("200").Inc()
+		c.WithLabelValues("404").Inc()
+		Expect(VerifyCollector(c).IsConsistent()).To(BeTrue())
+		Expect(For(Default).VerifyCollector(c).GomegaString()).To(BeEmpty())
+	})
+
+	It("reports inconsistencies", func() {
+		foo := prometheus.NewDesc("foo", "foo.", []string{"code"}, nil)
+		bar := prometheus.NewDesc("bar", "bar.", nil, nil)
+		baz := prometheus.NewDesc("baz", "baz.", nil, nil)
+		report := collectorReport(&funcCollector{
+			describe: func(ch chan<- *prometheus.Desc) {
+				ch <- foo
+				ch <- bar
+			},
+			collect: func(round int, ch chan<- prometheus.Metric) {
+				ch <- prometheus.MustNewConstMetric(bar, prometheus.GaugeValue, 1)
+				ch <- prometheus.MustNewConstMetric(baz, prometheus.GaugeValue, 1)
+				ch <- prometheus.NewInvalidMetric(foo, errors.New("D'OH!"))
+				if round > 0 {
+					ch <- prometheus.MustNewConstMetric(
+						prometheus.NewDesc("bar", "changed bar.", nil, nil), prometheus.CounterValue, 1)
+				}
+			},
+		}, DefaultVerifyTimeout)
+		Expect(report.IsConsistent()).To(BeFalse())
+		Expect(report).To(Equal(CollectorReport{
+			Uncollected:   []string{"foo{constLabels: {}, variableLabels: {code}}"},
+			Undescribed:   []string{"baz{constLabels: {}, variableLabels: {}}"},
+			UnstableHelp:  []string{`bar: "bar.", "changed bar."`},
+			UnstableTypes: []string{"bar: gauge, counter"},
+			Errors:        []string{"foo: D'OH!", "foo: D'OH!"},
+		}))
+		Expect(report.GomegaString()).To(Equal(`warning: described but never collected:
+    foo{constLabels: {}, variableLabels: {code}}
+collected but not described:
+    baz{constLabels: {}, variableLabels: {}}
+unstable help texts:
+    bar: "bar.", "changed bar."
+unstable metric types:
+    bar: gauge, counter
+errors:
+    foo: D'OH!
+    foo: D'OH!`))
+	})
+
+	It("reports unchecked collectors", func() {
+		report := collectorReport(&funcCollector{
+			collect: func(round int, ch chan<- prometheus.Metric) {
+				ch <- prometheus.MustNewConstMetric(
+					prometheus.NewDesc("foo", "foo.", nil, nil), prometheus.GaugeValue, 1)
+			},
+		}, DefaultVerifyTimeout)
+		Expect(report).To(Equal(CollectorReport{Unchecked: true}))
+		Expect(report.IsConsistent()).To(BeTrue())
+		Expect(report.HasWarnings()).To(BeTrue())
+		Expect(report.GomegaString()).To(Equal("warning: unchecked collector: Describe sent no descriptors"))
+	})
+
+	It("only warns about uncollected metrics", func() {
+		c := prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "foo_total",
+			Help: "foo.",
+		}, []string{"code"})
+		report := VerifyCollector(c)
+		Expect(report.IsConsistent()).To(BeTrue())
+		Expect(report.HasWarnings()).To(BeTrue())
+		Expect(report.Uncollected).To(ConsistOf("foo_total{constLabels: {}, variableLabels: {code}}"))
+	})
+
+	It("reports panicking collectors", func() {
+		desc := prometheus.NewDesc("foo", "foo.", nil, nil)
+		report := collectorReport(&funcCollector{
+			describe: func(ch chan<- *prometheus.Desc) {
+				ch <- desc
+				panic("D'OH!")
+			},
+			collect: func(round int, ch chan<- prometheus.Metric) {
+				ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, 1)
+				if round > 0 {
+					panic("D'OH! again")
+				}
+			},
+		}, DefaultVerifyTimeout)
+		Expect(report.IsConsistent()).To(BeFalse())
+		Expect(report.Errors).To(ConsistOf("Describe panicked: D'OH!", "Collect panicked: D'OH! again"))
+		Expect(report.HasWarnings()).To(BeFalse())
+		Expect(report.Undescribed).To(BeEmpty())
+	})
+
+	It("reports stalling collectors", func() {
+		c := &stallingCollector{
+			desc:  prometheus.NewDesc("foo", "foo.", nil, nil),
+			stall: make(chan struct{}),
+		}
+		defer close(c.stall)
+		report := collectorReport(c, 100*time.Millisecond)
+		Expect(report.Errors).To(ConsistOf("Collect timed out after 100ms", "Collect timed out after 100ms"))
+		Expect(report.HasWarnings()).To(BeFalse())
+	})
+
+	It("matches consistent reports", func() {
+		Expect(CollectorReport{Unchecked: true}).To(beConsistent(false))
+		Expect(CollectorReport{Unchecked: true}).NotTo(beConsistent(true))
+		Expect(CollectorReport{Errors: []string{"D'OH!"}}).NotTo(beConsistent(false))
+		Expect(beConsistent(false).Match(nil)).Error().To(MatchError(
+			ContainSubstring("beConsistent matcher expects a CollectorReport")))
+		Expect(beConsistent(false).NegatedFailureMessage(CollectorReport{})).To(
+			Equal("Expected collector not to be consistent"))
+	})
+
+	It("names metric types", func() {
+		Expect(metricTypeName(&prommodel.Metric{})).To(Equal("unknown"))
+	})
+
+	When("things fail", Serial, func() {
+
+		var g Gomega
+		var msg string
+
+		BeforeEach(func() {
+			msg = ""
+			g = NewGomega(func(message string, callerSkip ...int) { msg = message })
+		})
+
+		It("fails given an inconsistent collector", func() {
+			verifyCollector(g, &funcCollector{
+				collect: func(round int, ch chan<- prometheus.Metric) {
+					panic("D'OH!")
+				},
+			})
+			Expect(msg).To(HavePrefix("Expected collector to be consistent, but verification reported\n    warning: unchecked collector: Describe sent no descriptors\n    errors:\n        Collect panicked: D'OH!"))
+		})
+
+		It("fails on warnings only when asked to", func() {
+			c := prometheus.NewCounterVec(prometheus.CounterOpts{
+				Name: "foo_total",
+				Help: "foo.",
+			}, []string{"code"})
+			verifyCollector(g, c)
+			Expect(msg).To(BeEmpty())
+			For(g).VerifyCollector(c, TreatWarningsAsErrors(), VerifyTimeout(time.Second))
+			Expect(msg).To(HavePrefix("Expected collector to be consistent, but verification reported\n    warning: described but never collected:\n        foo_total"))
+		})
+
+	})
+
+})